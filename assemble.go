@@ -0,0 +1,176 @@
+package gohosts
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// FragmentExt is the file extension of the fragments assembled into the hosts file.
+	FragmentExt = ".conf"
+	// FragmentTag is the metadata tag recording the fragment and line an assembled entry comes from,
+	// such as "fragment=10-base.conf:3".
+	FragmentTag = "fragment"
+)
+
+// FragmentConflict reports a hostname mapped to different IP addresses by two fragments.
+// The resolver uses the existing mapping, which comes first in the assembled hosts file.
+type FragmentConflict struct {
+	// Hostname is the conflicting hostname.
+	Hostname string
+	// Fragment, Line and IP locate the mapping shadowed by the existing one.
+	Fragment string
+	Line     int
+	IP       string
+	// ExistingFragment, ExistingLine and ExistingIP locate the mapping used by the resolver.
+	ExistingFragment string
+	ExistingLine     int
+	ExistingIP       string
+}
+
+func (c FragmentConflict) String() string {
+	return fmt.Sprintf("%s:%d: %s is mapped to %s, but %s:%d already maps it to %s",
+		c.Fragment, c.Line, c.Hostname, c.IP, c.ExistingFragment, c.ExistingLine, c.ExistingIP)
+}
+
+// fragmentBlock returns the name of the managed block holding the entries of the fragment.
+func fragmentBlock(dir, name string) string {
+	return filepath.Base(dir) + "/" + name
+}
+
+// Assemble compiles the fragments of the directory, the files with the FragmentExt extension such as
+// /etc/hosts.d/10-base.conf, into the hosts file. Each fragment is parsed like a hosts file and
+// rendered in its own managed block named after the directory and the fragment, in filename order.
+// Every assembled entry is tagged with its fragment and line. Blocks of fragments that no longer
+// exist are removed, the other entries are kept.
+//
+// The hostnames mapped to different IP addresses by several fragments are returned as conflicts,
+// the hosts file is assembled anyway.
+func (h *HostsFile) Assemble(dir string) ([]FragmentConflict, error) {
+	files, err := h.filesystem().ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fragments directory: %w", err)
+	}
+
+	var assembled []HostEntry
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasSuffix(name, FragmentExt) {
+			continue
+		}
+		if strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("invalid fragment name: %s", name)
+		}
+
+		entries, err := h.parseFragment(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		block := fragmentBlock(dir, name)
+		for _, entry := range entries {
+			tags := copyTags(entry.Tags)
+			if tags == nil {
+				tags = make(map[string]string)
+			}
+			tags[FragmentTag] = name + ":" + strconv.Itoa(entry.Line)
+
+			entry.Tags = tags
+			entry.Block = block
+			entry.Line = 0
+			assembled = append(assembled, entry)
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	defer h.edit("Assemble")()
+
+	// Replace the blocks of the previous assembly
+	prefix := fragmentBlock(dir, "")
+	var entries []HostEntry
+	for _, entry := range h.entries {
+		if !strings.HasPrefix(entry.Block, prefix) {
+			entries = append(entries, entry)
+		}
+	}
+	h.entries = append(entries, assembled...)
+
+	return fragmentConflicts(assembled), nil
+}
+
+// parseFragment reads and parses the fragment at the provided path, with the options of the hosts file.
+func (h *HostsFile) parseFragment(path string) ([]HostEntry, error) {
+	content, err := readFile(h.filesystem(), path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fragment: %w", err)
+	}
+
+	fragment := &HostsFile{ipv4MappedPolicy: h.ipv4MappedPolicy}
+	lines, err := fragment.splitLines(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fragment %s: %w", path, err)
+	}
+
+	entries, err := fragment.parseHosts(lines)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse fragment %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// fragmentConflicts returns the conflicts between the active mappings of the assembled entries.
+func fragmentConflicts(entries []HostEntry) []FragmentConflict {
+	type mapping struct {
+		ip       string
+		fragment string
+		line     int
+	}
+
+	// The first active mapping of each hostname, per address family
+	resolved := make(map[string]mapping)
+	var conflicts []FragmentConflict
+
+	for _, entry := range entries {
+		addr := entry.Addr()
+		if !entry.Active || !addr.IsValid() {
+			continue
+		}
+
+		fragment, line := splitFragmentTag(entry.Tags[FragmentTag])
+		for _, hostname := range entry.Hostnames {
+			key := hostnameKey(hostname) + "/" + strconv.FormatBool(addr.Is4())
+
+			first, ok := resolved[key]
+			if !ok {
+				resolved[key] = mapping{ip: entry.IP, fragment: fragment, line: line}
+				continue
+			}
+			if first.fragment != fragment && !sameIP(first.ip, entry.IP) {
+				conflicts = append(conflicts, FragmentConflict{
+					Hostname:         hostname,
+					Fragment:         fragment,
+					Line:             line,
+					IP:               entry.IP,
+					ExistingFragment: first.fragment,
+					ExistingLine:     first.line,
+					ExistingIP:       first.ip,
+				})
+			}
+		}
+	}
+
+	return conflicts
+}
+
+// splitFragmentTag returns the fragment name and line of the value of a FragmentTag.
+func splitFragmentTag(value string) (string, int) {
+	i := strings.LastIndex(value, ":")
+	if i == -1 {
+		return value, 0
+	}
+	line, _ := strconv.Atoi(value[i+1:])
+	return value[:i], line
+}
@@ -0,0 +1,109 @@
+package gohosts
+
+import (
+	"strings"
+	"testing"
+)
+
+const testAssembleHosts = "127.0.0.1 localhost\n# gohosts:begin hosts.d/90-old.conf\n10.0.0.9 old.local\n# gohosts:end hosts.d/90-old.conf\n"
+
+// writeFragments writes the fragments to /etc/hosts.d, by filename.
+func writeFragments(t *testing.T, m *MemFS, fragments map[string]string) {
+	t.Helper()
+
+	for name, content := range fragments {
+		if err := m.WriteFile("/etc/hosts.d/"+name, []byte(content), 0644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestAssemble(t *testing.T) {
+	h, m := newTestHostsFile(t, testAssembleHosts)
+	writeFragments(t, m, map[string]string{
+		"20-team.conf": "# team services\n10.0.0.2 web.local # owner=web\n# 10.0.0.3 db.local\n",
+		"10-base.conf": "\n10.0.0.1 api.local\n",
+		"README":       "not a fragment\n",
+	})
+
+	conflicts, err := h.Assemble("/etc/hosts.d")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(conflicts) != 0 {
+		t.Errorf("expected no conflicts, got %v", conflicts)
+	}
+
+	expected := []HostEntry{
+		{IP: "127.0.0.1", Hostnames: []string{"localhost"}, Active: true},
+		{IP: "10.0.0.1", Hostnames: []string{"api.local"}, Tags: map[string]string{"fragment": "10-base.conf:2"}, Active: true, Block: "hosts.d/10-base.conf"},
+		{IP: "10.0.0.2", Hostnames: []string{"web.local"}, Tags: map[string]string{"fragment": "20-team.conf:2", "owner": "web"}, Active: true, Block: "hosts.d/20-team.conf"},
+		{IP: "10.0.0.3", Hostnames: []string{"db.local"}, Tags: map[string]string{"fragment": "20-team.conf:3"}, Active: false, Block: "hosts.d/20-team.conf"},
+	}
+	if !Equal(h.entries, expected) {
+		t.Errorf("Entries do not match expected entries. \nGot: %v, \nExpected: %v", h.entries, expected)
+	}
+
+	var b strings.Builder
+	if _, err := h.WriteTo(&b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "127.0.0.1     localhost\n" +
+		"# gohosts:begin hosts.d/10-base.conf\n10.0.0.1     api.local     # fragment=10-base.conf:2\n# gohosts:end hosts.d/10-base.conf\n" +
+		"# gohosts:begin hosts.d/20-team.conf\n10.0.0.2     web.local     # fragment=20-team.conf:2 owner=web\n" +
+		"# 10.0.0.3     db.local     # fragment=20-team.conf:3\n# gohosts:end hosts.d/20-team.conf\n"
+	if b.String() != want {
+		t.Errorf("unexpected output:\n%s", b.String())
+	}
+
+	// Assembling again gives the same result
+	if _, err := h.Assemble("/etc/hosts.d"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !Equal(h.entries, expected) {
+		t.Errorf("expected assembling twice to be idempotent, got %v", h.entries)
+	}
+}
+
+func TestAssemble_Conflicts(t *testing.T) {
+	h, m := newTestHostsFile(t, testAssembleHosts)
+	writeFragments(t, m, map[string]string{
+		"10-base.conf":  "10.0.0.1 api.local web.local\n::1 api.local\n",
+		"20-team.conf":  "10.0.0.1 web.local\n\n10.0.0.2 API.local\n# 10.0.0.3 web.local\n",
+		"30-other.conf": "fe80::1 api.local\n",
+	})
+
+	conflicts, err := h.Assemble("/etc/hosts.d")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []FragmentConflict{
+		{Hostname: "api.local", Fragment: "20-team.conf", Line: 3, IP: "10.0.0.2", ExistingFragment: "10-base.conf", ExistingLine: 1, ExistingIP: "10.0.0.1"},
+		{Hostname: "api.local", Fragment: "30-other.conf", Line: 1, IP: "fe80::1", ExistingFragment: "10-base.conf", ExistingLine: 2, ExistingIP: "::1"},
+	}
+	if len(conflicts) != len(expected) {
+		t.Fatalf("expected %d conflicts, got %v", len(expected), conflicts)
+	}
+	for i := range expected {
+		if conflicts[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected[i], conflicts[i])
+		}
+	}
+
+	if s := conflicts[0].String(); s != "20-team.conf:3: api.local is mapped to 10.0.0.2, but 10-base.conf:1 already maps it to 10.0.0.1" {
+		t.Errorf("unexpected message: %s", s)
+	}
+}
+
+func TestAssemble_Errors(t *testing.T) {
+	h, m := newTestHostsFile(t, testAssembleHosts)
+	writeFragments(t, m, map[string]string{"my hosts.conf": "10.0.0.1 api.local\n"})
+
+	if _, err := h.Assemble("/etc/missing.d"); err == nil {
+		t.Errorf("Expected an error for a missing directory")
+	}
+	if _, err := h.Assemble("/etc/hosts.d"); err == nil {
+		t.Errorf("Expected an error for a fragment name with spaces")
+	}
+}
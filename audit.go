@@ -0,0 +1,248 @@
+package gohosts
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// AuditAction is the kind of write recorded in the audit log.
+type AuditAction string
+
+const (
+	// AuditSave records a Save of the hosts file.
+	AuditSave AuditAction = "save"
+	// AuditRestore records a RestoreBackup of the hosts file.
+	AuditRestore AuditAction = "restore"
+)
+
+// AuditRecord is a record of the audit log, one JSON object per line.
+type AuditRecord struct {
+	Time   time.Time   `json:"time"`
+	Action AuditAction `json:"action"`
+	// Path is the path of the hosts file written.
+	Path string `json:"path"`
+	// UID and User identify the user running the process, User is empty if it cannot be looked up.
+	UID  string `json:"uid"`
+	User string `json:"user,omitempty"`
+	// Process and PID identify the process that wrote the hosts file.
+	Process string `json:"process"`
+	PID     int    `json:"pid"`
+	// Reason is the reason supplied with SaveWithReason, RestoreBackupWithReason or
+	// WithAuditReason, if any.
+	Reason string `json:"reason,omitempty"`
+	// Changes are the entry changes between the hosts file before and after the write.
+	Changes []Change `json:"changes"`
+	// Backup is the filename of the backup created before a save, empty if the hosts file
+	// did not exist.
+	Backup string `json:"backup,omitempty"`
+	// Restored is the filename of the backup a restore restored the hosts file from.
+	Restored string `json:"restored,omitempty"`
+}
+
+// WithAuditLog is a HostsOption that appends a record to the audit log at the provided path
+// on every Save and RestoreBackup of the hosts file. The audit log is disabled by default.
+// If the record cannot be written, the hosts file is kept written and the error wraps ErrAuditLog.
+func WithAuditLog(path string) HostsOption {
+	return func(h *HostsFile) {
+		h.auditLog = path
+	}
+}
+
+// WithAuditReason is a HostsOption that sets the reason recorded in the audit log for the writes
+// of the hosts file made without a reason of their own, such as Save, Apply and ActivateProfile.
+// Use SaveWithReason and RestoreBackupWithReason to supply the reason of a single write.
+func WithAuditReason(reason string) HostsOption {
+	return func(h *HostsFile) {
+		h.auditReason = reason
+	}
+}
+
+// diskEntries returns the entries of the hosts file on disk, to compute the changes of a write for
+// the audit log and the hooks. It returns nil if neither is enabled.
+func (h *HostsFile) diskEntries() ([]HostEntry, error) {
+	if h.auditLog == "" && !h.hasHooks() {
+		return nil, nil
+	}
+
+	entries, err := h.readEntries(h.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hosts file for the changes: %w", err)
+	}
+	return entries, nil
+}
+
+// readEntries reads and parses the hosts file at the provided path, with the options of the hosts
+// file, without changing its content.
+func (h *HostsFile) readEntries(path string) ([]HostEntry, error) {
+	file := &HostsFile{
+		path:              path,
+		fsys:              h.fsys,
+		formatter:         h.formatter,
+		hostnameValidator: h.hostnameValidator,
+		ipv4MappedPolicy:  h.ipv4MappedPolicy,
+	}
+	lines, err := file.readHosts()
+	if err != nil {
+		return nil, err
+	}
+	return file.parseHosts(lines)
+}
+
+// audit appends a record of a write of the hosts file and its changes to the audit log, if enabled.
+func (h *HostsFile) audit(action AuditAction, changes []Change, backup, restored, reason string) error {
+	if h.auditLog == "" {
+		return nil
+	}
+
+	uid, username := currentUser()
+	record := AuditRecord{
+		Time:     h.now(),
+		Action:   action,
+		Path:     h.path,
+		UID:      uid,
+		User:     username,
+		Process:  filepath.Base(os.Args[0]),
+		PID:      os.Getpid(),
+		Reason:   reason,
+		Changes:  changes,
+		Backup:   backup,
+		Restored: restored,
+	}
+
+	if err := appendAuditRecord(h.filesystem(), h.auditLog, record); err != nil {
+		return fmt.Errorf("hosts file written, but %w: %w", ErrAuditLog, err)
+	}
+	return nil
+}
+
+// currentUser returns the uid and the username of the user running the process.
+func currentUser() (string, string) {
+	u, err := user.Current()
+	if err != nil {
+		return strconv.Itoa(os.Getuid()), ""
+	}
+	return u.Uid, u.Username
+}
+
+// appendAuditRecord appends the record as a JSON line to the audit log at the provided path.
+func appendAuditRecord(fsys FS, path string, record AuditRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	file, err := fsys.Append(path)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(append(line, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// ReadAuditLog reads the records of an audit log, in the order they were written.
+func ReadAuditLog(r io.Reader) ([]AuditRecord, error) {
+	var records []AuditRecord
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var record AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("invalid audit record on line %d: %w", line, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	return records, nil
+}
+
+// AuditQuery selects records of the audit log. Zero fields match every record.
+type AuditQuery struct {
+	// Since and Until select the records written in the time range, both inclusive.
+	Since time.Time
+	Until time.Time
+	// User selects the records written by the user, matched against both the username and the uid.
+	User   string
+	Action AuditAction
+	// Hostname selects the records with a change to an entry of the hostname.
+	Hostname string
+}
+
+// Match checks if the record is selected by the query.
+func (q AuditQuery) Match(record AuditRecord) bool {
+	switch {
+	case !q.Since.IsZero() && record.Time.Before(q.Since):
+		return false
+	case !q.Until.IsZero() && record.Time.After(q.Until):
+		return false
+	case q.User != "" && q.User != record.User && q.User != record.UID:
+		return false
+	case q.Action != "" && q.Action != record.Action:
+		return false
+	}
+
+	if q.Hostname == "" {
+		return true
+	}
+	hostname := Hostname(q.Hostname)
+	for _, change := range record.Changes {
+		if hostname(change.Before) || hostname(change.After) {
+			return true
+		}
+	}
+	return false
+}
+
+// AuditLog returns the records of the audit log of the hosts file selected by the query.
+// A missing audit log has no records.
+func (h *HostsFile) AuditLog(query AuditQuery) ([]AuditRecord, error) {
+	if h.auditLog == "" {
+		return nil, fmt.Errorf("audit log is not enabled, use WithAuditLog")
+	}
+
+	// The audit log is appended to while the hosts file is locked
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	file, err := h.filesystem().Open(h.auditLog)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	records, err := ReadAuditLog(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []AuditRecord
+	for _, record := range records {
+		if query.Match(record) {
+			selected = append(selected, record)
+		}
+	}
+	return selected, nil
+}
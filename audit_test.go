@@ -0,0 +1,203 @@
+package gohosts
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testAuditHosts = "127.0.0.1 localhost\n10.0.0.1 api.local\n"
+
+func TestAudit_Save(t *testing.T) {
+	h, _ := newTestHostsFile(t, testAuditHosts, WithAuditLog("/etc/gohosts.log"))
+
+	if err := h.Add("10.0.0.2", []string{"web.local"}, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := h.Remove("10.0.0.1", []string{"api.local"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := h.SaveWithReason("OPS-12 move web"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	records, err := h.AuditLog(AuditQuery{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %v", records)
+	}
+
+	record := records[0]
+	uid, username := currentUser()
+	switch {
+	case !record.Time.Equal(testTime):
+		t.Errorf("unexpected time: %v", record.Time)
+	case record.Action != AuditSave:
+		t.Errorf("unexpected action: %s", record.Action)
+	case record.Path != "/etc/hosts":
+		t.Errorf("unexpected path: %s", record.Path)
+	case record.UID != uid || record.User != username:
+		t.Errorf("unexpected user: %s (%s)", record.User, record.UID)
+	case record.Process == "" || record.PID == 0:
+		t.Errorf("unexpected process: %s (%d)", record.Process, record.PID)
+	case record.Reason != "OPS-12 move web":
+		t.Errorf("unexpected reason: %s", record.Reason)
+	case record.Backup != "hosts_gohosts_20240101120000.bak":
+		t.Errorf("unexpected backup: %s", record.Backup)
+	}
+
+	if len(record.Changes) != 2 {
+		t.Fatalf("expected 2 changes, got %v", record.Changes)
+	}
+	if record.Changes[0].Action != ActionAdd || record.Changes[0].After.IP != "10.0.0.2" {
+		t.Errorf("unexpected change: %v", record.Changes[0])
+	}
+	if record.Changes[1].Action != ActionRemove || record.Changes[1].Before.IP != "10.0.0.1" {
+		t.Errorf("unexpected change: %v", record.Changes[1])
+	}
+
+	// The reason is only recorded for one write
+	if err := h.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	records, _ = h.AuditLog(AuditQuery{})
+	if len(records) != 2 || records[1].Reason != "" || len(records[1].Changes) != 0 {
+		t.Errorf("unexpected records: %v", records)
+	}
+}
+
+func TestAudit_Restore(t *testing.T) {
+	h, _ := newTestHostsFile(t, testAuditHosts, WithAuditLog("/etc/gohosts.log"), WithAuditReason("scheduled"))
+
+	if err := h.Add("10.0.0.2", []string{"web.local"}, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := h.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := h.RestoreBackupWithReason("OPS-13 rollback"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	records, err := h.AuditLog(AuditQuery{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %v", records)
+	}
+	// The reason of the hosts file is used unless the write supplies its own
+	if records[0].Reason != "scheduled" || records[1].Reason != "OPS-13 rollback" {
+		t.Errorf("unexpected reasons: %s, %s", records[0].Reason, records[1].Reason)
+	}
+
+	records = records[1:]
+	if records[0].Action != AuditRestore {
+		t.Errorf("unexpected action: %s", records[0].Action)
+	}
+	if records[0].Restored != "hosts_gohosts_20240101120000.bak" || records[0].Backup != "" {
+		t.Errorf("unexpected backups: %s, %s", records[0].Backup, records[0].Restored)
+	}
+	if len(records[0].Changes) != 1 || records[0].Changes[0].Action != ActionRemove {
+		t.Errorf("unexpected changes: %v", records[0].Changes)
+	}
+}
+
+func TestAudit_Disabled(t *testing.T) {
+	h, _ := newTestHostsFile(t, testAuditHosts)
+	if err := h.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := h.AuditLog(AuditQuery{}); err == nil {
+		t.Errorf("Expected an error for a hosts file without audit log")
+	}
+}
+
+func TestAudit_WriteError(t *testing.T) {
+	// The directory of the audit log does not exist
+	h, m := newTestHostsFile(t, testAuditHosts, WithAuditLog("/var/log/gohosts.log"))
+	saved := 0
+	h.OnAfterSave(func(event HookEvent) error {
+		saved++
+		return nil
+	})
+
+	if err := h.Save(); !errors.Is(err, ErrAuditLog) {
+		t.Errorf("Expected ErrAuditLog for an audit log that cannot be written, got %v", err)
+	}
+
+	// The hosts file is written, the plan is kept applied and the hooks run anyway
+	plan, err := h.Plan(Scope{Block: "dev"}, HostEntry{IP: "10.0.0.2", Hostnames: []string{"web.local"}, Active: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := h.Apply(plan); !errors.Is(err, ErrAuditLog) {
+		t.Errorf("Expected ErrAuditLog for an audit log that cannot be written, got %v", err)
+	}
+	if saved != 2 {
+		t.Errorf("expected the after save hooks to run twice, ran %d times", saved)
+	}
+
+	data, err := m.ReadFile("/etc/hosts")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != h.Snapshot().String() {
+		t.Errorf("expected the entries to match the hosts file, got:\n%s\nwritten:\n%s", h.Snapshot().String(), data)
+	}
+}
+
+func TestReadAuditLog(t *testing.T) {
+	log := `{"time":"2024-01-01T12:00:00Z","action":"save","path":"/etc/hosts","uid":"0","user":"root","process":"gohosts","pid":1,"changes":[{"action":"add","after":{"line":0,"ip":"10.0.0.1","hostnames":["api.local"],"active":true,"block":""}}],"backup":"hosts_gohosts_20240101120000.bak"}
+
+{"time":"2024-01-02T12:00:00Z","action":"restore","path":"/etc/hosts","uid":"1000","user":"alice","process":"deploy","pid":2,"changes":[],"restored":"hosts_gohosts_20240101120000.bak"}
+`
+	records, err := ReadAuditLog(strings.NewReader(log))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %v", records)
+	}
+
+	change := records[0].Changes[0]
+	if change.Action != ActionAdd || change.After.IP != "10.0.0.1" {
+		t.Errorf("unexpected change: %v", change)
+	}
+
+	tests := []struct {
+		name     string
+		query    AuditQuery
+		expected int
+	}{
+		{"all", AuditQuery{}, 2},
+		{"since", AuditQuery{Since: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}, 1},
+		{"until", AuditQuery{Until: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}, 1},
+		{"username", AuditQuery{User: "alice"}, 1},
+		{"uid", AuditQuery{User: "0"}, 1},
+		{"action", AuditQuery{Action: AuditRestore}, 1},
+		{"hostname", AuditQuery{Hostname: "API.local"}, 1},
+		{"unknown hostname", AuditQuery{Hostname: "web.local"}, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			matched := 0
+			for _, record := range records {
+				if test.query.Match(record) {
+					matched++
+				}
+			}
+			if matched != test.expected {
+				t.Errorf("expected %d records, got %d", test.expected, matched)
+			}
+		})
+	}
+
+	if _, err := ReadAuditLog(strings.NewReader("{invalid\n")); err == nil {
+		t.Errorf("Expected an error for an invalid audit record")
+	}
+}
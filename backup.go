@@ -1,32 +1,80 @@
 package gohosts
 
 import (
+	"errors"
 	"fmt"
 	"io"
-	"os"
+	"io/fs"
 	"path/filepath"
 	"strings"
-	"time"
 )
 
 const (
 	BackupFileInfix = "gohosts"
 )
 
-// CreateBackup creates a backup of the hosts file with the format <path>_<BackupFileInfix>_<timestamp>.bak
+// CreateBackup creates a backup of the hosts file with the format <path>_<BackupFileInfix>_<timestamp>.bak,
+// or <path>_<BackupFileInfix>_<timestamp>_<counter>.bak for the following backups made in the same second.
 func (h *HostsFile) CreateBackup() error {
-	backup := fmt.Sprintf("%s_%s_%s.bak", h.path, BackupFileInfix, time.Now().Format("20060102150405"))
-	err := copyFile(h.path, backup)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	_, err := h.createBackup()
+	return err
+}
+
+// createBackup creates a backup of the hosts file and returns the filename of the backup,
+// which identifies it in the audit log.
+func (h *HostsFile) createBackup() (string, error) {
+	backup, err := h.backupName()
 	if err != nil {
-		return fmt.Errorf("failed to create backup: %v", err)
+		return "", &PathError{Op: "backup", Path: h.path, Err: err}
 	}
 
-	return nil
+	err = copyFile(h.filesystem(), h.path, backup)
+	if err != nil {
+		return "", &PathError{Op: "backup", Path: h.path, Err: err}
+	}
+
+	return filepath.Base(backup), nil
+}
+
+// maxBackupsPerSecond is the number of backups that can be made in the same second, the counter
+// suffix is zero padded so backups sort by filename in the order they were made.
+const maxBackupsPerSecond = 1000
+
+// backupName returns the path of a new backup of the hosts file. Backups made in the same second
+// get a counter suffix, an existing backup is never replaced.
+func (h *HostsFile) backupName() (string, error) {
+	prefix := fmt.Sprintf("%s_%s_%s", h.path, BackupFileInfix, h.now().Format("20060102150405"))
+
+	for i := 0; i < maxBackupsPerSecond; i++ {
+		backup := prefix + ".bak"
+		if i > 0 {
+			backup = fmt.Sprintf("%s_%03d.bak", prefix, i)
+		}
+
+		_, err := h.filesystem().Stat(backup)
+		if errors.Is(err, fs.ErrNotExist) {
+			return backup, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return "", fmt.Errorf("too many backups in the same second: %w", fs.ErrExist)
 }
 
 // RestoreBackup restores the hosts file from the latest backup file or a specific backup file
 // based on the rollback count if provided (default is 1)
 func (h *HostsFile) RestoreBackup(rollback ...int) error {
+	return h.RestoreBackupWithReason(h.auditReason, rollback...)
+}
+
+// RestoreBackupWithReason restores the hosts file like RestoreBackup, and records the provided
+// reason for the restore in the audit log.
+func (h *HostsFile) RestoreBackupWithReason(reason string, rollback ...int) error {
 	var rollbackCount int
 	// Only one argument is expected
 	if len(rollback) > 0 {
@@ -36,40 +84,77 @@ func (h *HostsFile) RestoreBackup(rollback ...int) error {
 		rollbackCount = 1
 	}
 
+	h.mu.Lock()
+	changes, backupFile, auditErr, err := h.restore(rollbackCount, reason)
+	h.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// The backup was restored even if the audit log was not written, the hooks run anyway
+	return errors.Join(auditErr, h.runHooks(HookRestore, changes, backupFile))
+}
+
+// restore restores the hosts file from the backup selected by the rollback count and records the
+// restore in the audit log with the provided reason. It returns the changes of the restore and the backup restored, for the
+// restore hooks. A failure to write the audit log is returned as auditErr, as for save.
+func (h *HostsFile) restore(rollbackCount int, reason string) (changes []Change, backupFile string, auditErr, err error) {
+	before, err := h.diskEntries()
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	backupFile, err = h.restoreBackup(rollbackCount)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	after, err := h.diskEntries()
+	if err != nil {
+		return nil, "", nil, err
+	}
+	changes = Diff(before, after)
+
+	return changes, backupFile, h.audit(AuditRestore, changes, "", backupFile, reason), nil
+}
+
+// restoreBackup restores the hosts file from the backup selected by the rollback count and
+// returns the filename of the restored backup.
+func (h *HostsFile) restoreBackup(rollbackCount int) (string, error) {
 	if rollbackCount < 1 {
-		return fmt.Errorf("rollback count must be greater than 0")
+		return "", fmt.Errorf("rollback count must be greater than 0")
 	}
 
-	backupFiles, err := getBackupFiles(h.path)
+	backupFiles, err := getBackupFiles(h.filesystem(), h.path)
 	if err != nil {
-		return err
+		return "", &PathError{Op: "restore", Path: h.path, Err: err}
 	}
 
 	if rollbackCount > len(backupFiles) {
-		return fmt.Errorf("rollback count is greater than the number of backup files")
+		return "", fmt.Errorf("%w: rollback count is greater than the number of backup files", ErrNoBackups)
 	}
 
 	// The backup file to restore
 	backupFile := backupFiles[len(backupFiles)-rollbackCount]
 
-	err = copyFile(filepath.Join(filepath.Dir(h.path), backupFile), h.path)
+	err = copyFile(h.filesystem(), filepath.Join(filepath.Dir(h.path), backupFile), h.path)
 	if err != nil {
-		return fmt.Errorf("failed to restore backup: %v", err)
+		return "", &PathError{Op: "restore", Path: h.path, Err: err}
 	}
 
-	return nil
+	return backupFile, nil
 }
 
 // getBackupFiles returns a list of backup files for the hosts file
-func getBackupFiles(path string) ([]string, error) {
-	files, err := os.ReadDir(filepath.Dir(path))
+func getBackupFiles(fsys FS, path string) ([]string, error) {
+	files, err := fsys.ReadDir(filepath.Dir(path))
 	if err != nil {
 		return nil, err
 	}
 
 	var backupFiles []string
 	for _, file := range files {
-		// Check if the file is a backup file with the format <path>_<BackupFileInfix>_<timestamp>.bak
+		// Check if the file is a backup file with the format <path>_<BackupFileInfix>_<timestamp>[_<counter>].bak
 		if strings.HasPrefix(file.Name(), filepath.Base(path)+"_"+BackupFileInfix+"_") && strings.HasSuffix(file.Name(), ".bak") {
 			backupFiles = append(backupFiles, file.Name())
 		}
@@ -84,14 +169,14 @@ func getBackupFiles(path string) ([]string, error) {
 }
 
 // copyFile copies a file from src to dst
-func copyFile(src, dst string) error {
-	srcFile, err := os.Open(src)
+func copyFile(fsys FS, src, dst string) error {
+	srcFile, err := fsys.Open(src)
 	if err != nil {
 		return err
 	}
 	defer srcFile.Close()
 
-	dstFile, err := os.Create(dst)
+	dstFile, err := fsys.Create(dst)
 	if err != nil {
 		return err
 	}
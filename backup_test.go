@@ -3,7 +3,9 @@ package gohosts
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestCreateBackup(t *testing.T) {
@@ -21,7 +23,7 @@ func TestCreateBackup(t *testing.T) {
 		t.Errorf("Failed to create backup: %v", err)
 	}
 
-	backupFiles, err := getBackupFiles(hostsFile.path)
+	backupFiles, err := getBackupFiles(OSFS{}, hostsFile.path)
 	if err != nil {
 		t.Errorf("Failed to get backup files: %v", err)
 	}
@@ -186,7 +188,7 @@ func TestGetBackupFiles(t *testing.T) {
 		t.Fatalf("Failed to create backup file: %v", err)
 	}
 
-	backupFiles, err := getBackupFiles(hostsPath)
+	backupFiles, err := getBackupFiles(OSFS{}, hostsPath)
 	if err != nil {
 		t.Errorf("Failed to get backup files: %v", err)
 	}
@@ -215,7 +217,7 @@ func TestCopyFile(t *testing.T) {
 	defer os.Remove(dstFile.Name())
 	defer dstFile.Close()
 
-	err = copyFile(srcFile.Name(), dstFile.Name())
+	err = copyFile(OSFS{}, srcFile.Name(), dstFile.Name())
 	if err != nil {
 		t.Errorf("Failed to copy file: %v", err)
 	}
@@ -237,7 +239,7 @@ func TestCopyFile_SourceNotFound(t *testing.T) {
 	defer os.Remove(dstFile.Name())
 	defer dstFile.Close()
 
-	err = copyFile("/invalid/source", dstFile.Name())
+	err = copyFile(OSFS{}, "/invalid/source", dstFile.Name())
 	if err == nil {
 		t.Error("Expected an error for source not found")
 	}
@@ -251,8 +253,84 @@ func TestCopyFile_DestinationNotFound(t *testing.T) {
 	defer os.Remove(srcFile.Name())
 	defer srcFile.Close()
 
-	err = copyFile(srcFile.Name(), "/invalid/destination")
+	err = copyFile(OSFS{}, srcFile.Name(), "/invalid/destination")
 	if err == nil {
 		t.Error("Expected an error for destination not found")
 	}
 }
+
+func TestCreateBackup_Clock(t *testing.T) {
+	now := time.Date(2024, time.March, 2, 10, 30, 0, 0, time.UTC)
+	h, m := newTestHostsFile(t, "127.0.0.1 localhost\n", WithClock(fixedClock(now)))
+
+	if err := h.CreateBackup(); err != nil {
+		t.Fatalf("Failed to create backup: %v", err)
+	}
+	if _, err := m.Stat("/etc/hosts_gohosts_20240302103000.bak"); err != nil {
+		t.Errorf("expected the backup to be named after the clock time: %v", err)
+	}
+}
+
+func TestSave_SameSecond(t *testing.T) {
+	h, m := newTestHostsFile(t, "127.0.0.1 localhost\n", WithAuditLog("/etc/gohosts.log"))
+
+	for _, hostname := range []string{"a.local", "b.local"} {
+		if err := h.Add("10.0.0.1", []string{hostname}, ""); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := h.Save(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// Each save keeps its own backup, holding the content before it
+	expected := map[string]bool{
+		"hosts_gohosts_20240101120000.bak":     false,
+		"hosts_gohosts_20240101120000_001.bak": true,
+	}
+	for backup, hasFirstAdd := range expected {
+		data, err := m.ReadFile("/etc/" + backup)
+		if err != nil {
+			t.Fatalf("expected the backup %s: %v", backup, err)
+		}
+		if strings.Contains(string(data), "a.local") != hasFirstAdd || strings.Contains(string(data), "b.local") {
+			t.Errorf("unexpected content of %s: %q", backup, data)
+		}
+	}
+
+	records, err := h.AuditLog(AuditQuery{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(records) != 2 || records[0].Backup == records[1].Backup {
+		t.Errorf("expected the audit records to refer to distinct backups, got %v", records)
+	}
+
+	backups, err := getBackupFiles(m, "/etc/hosts")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(backups) != 2 || backups[1] != "hosts_gohosts_20240101120000_001.bak" {
+		t.Errorf("expected the backups in the order they were made, got %v", backups)
+	}
+}
+
+func TestCreateBackup_Existing(t *testing.T) {
+	h, m := newTestHostsFile(t, "127.0.0.1 localhost\n")
+	if err := m.WriteFile("/etc/hosts_gohosts_20240101120000.bak", []byte("original\n"), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := h.CreateBackup(); err != nil {
+		t.Fatalf("Failed to create backup: %v", err)
+	}
+
+	// The existing backup is not replaced
+	data, err := m.ReadFile("/etc/hosts_gohosts_20240101120000.bak")
+	if err != nil || string(data) != "original\n" {
+		t.Errorf("expected the existing backup to be kept, got %q, %v", data, err)
+	}
+	if _, err := m.Stat("/etc/hosts_gohosts_20240101120000_001.bak"); err != nil {
+		t.Errorf("expected a new backup with a counter suffix: %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package gohosts
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const (
+	// BlockBeginMarker is the comment keyword that opens a managed block.
+	// A block is written as "# gohosts:begin <name>" ... "# gohosts:end <name>".
+	BlockBeginMarker = "gohosts:begin"
+	// BlockEndMarker is the comment keyword that closes a managed block.
+	BlockEndMarker = "gohosts:end"
+)
+
+// section is a group of entries written together, either outside of any block
+// or inside a named managed block.
+type section struct {
+	block   string
+	entries []HostEntry
+}
+
+// entrySections groups the entries by block. Entries that are not part of a block
+// come first, followed by each block in the order it first appears.
+func entrySections(entries []HostEntry) []section {
+	sections := []section{{}}
+	index := map[string]int{"": 0}
+
+	for _, entry := range entries {
+		i, ok := index[entry.Block]
+		if !ok {
+			i = len(sections)
+			index[entry.Block] = i
+			sections = append(sections, section{block: entry.Block})
+		}
+		sections[i].entries = append(sections[i].entries, entry)
+	}
+
+	return sections
+}
+
+// parseBlockMarker checks if the provided comment text (without the leading '#')
+// is a block marker, and returns the block name and whether it opens the block.
+func parseBlockMarker(text string) (name string, begin bool, ok bool) {
+	fields := strings.Fields(text)
+	if len(fields) != 2 {
+		return "", false, false
+	}
+
+	switch fields[0] {
+	case BlockBeginMarker:
+		return fields[1], true, true
+	case BlockEndMarker:
+		return fields[1], false, true
+	default:
+		return "", false, false
+	}
+}
+
+// validateBlock checks that the block name can be written in the block markers and parsed back.
+func validateBlock(name string) error {
+	if strings.IndexFunc(name, unicode.IsSpace) != -1 {
+		return fmt.Errorf("%w: %q", ErrInvalidBlock, name)
+	}
+	return nil
+}
+
+// blockBeginLine returns the line that opens the named block.
+func blockBeginLine(name string) string {
+	return "# " + BlockBeginMarker + " " + name
+}
+
+// blockEndLine returns the line that closes the named block.
+func blockEndLine(name string) string {
+	return "# " + BlockEndMarker + " " + name
+}
+
+// Blocks returns the names of the managed blocks in the hosts file, in the order
+// they first appear.
+func (h *HostsFile) Blocks() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var blocks []string
+	for _, section := range entrySections(h.entries) {
+		if section.block != "" {
+			blocks = append(blocks, section.block)
+		}
+	}
+	return blocks
+}
@@ -0,0 +1,23 @@
+package gohosts
+
+import "time"
+
+// Clock provides the current time, used for the backup timestamps and to prune expired entries.
+type Clock interface {
+	Now() time.Time
+}
+
+// WithClock is a HostsOption that sets the clock of the hosts file, the system clock by default.
+func WithClock(c Clock) HostsOption {
+	return func(h *HostsFile) {
+		h.clock = c
+	}
+}
+
+// now returns the current time according to the clock of the hosts file.
+func (h *HostsFile) now() time.Time {
+	if h.clock == nil {
+		return time.Now()
+	}
+	return h.clock.Now()
+}
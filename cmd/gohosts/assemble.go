@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/aymansor/gohosts"
+)
+
+// runAssemble compiles the fragments of the hosts.d directory into the hosts file and saves it.
+// Conflicts between fragments are reported on the standard error.
+func runAssemble(hosts *gohosts.HostsFile, args []string) error {
+	flags := flag.NewFlagSet("assemble", flag.ExitOnError)
+	dir := flags.String("dir", "/etc/hosts.d", "directory of the fragments")
+	dryRun := flags.Bool("dry-run", false, "print the assembled hosts file instead of saving it")
+	strict := flags.Bool("strict", false, "fail without saving if fragments conflict")
+	flags.Parse(args)
+
+	conflicts, err := hosts.Assemble(*dir)
+	if err != nil {
+		return err
+	}
+
+	for _, conflict := range conflicts {
+		fmt.Fprintf(os.Stderr, "conflict: %s\n", conflict)
+	}
+	if *strict && len(conflicts) > 0 {
+		return fmt.Errorf("%d conflicts between fragments", len(conflicts))
+	}
+
+	if *dryRun {
+		_, err := hosts.WriteTo(os.Stdout)
+		return err
+	}
+	return hosts.Save()
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aymansor/gohosts"
+)
+
+// runAudit prints the records of the audit log selected by the flags, with their changes.
+func runAudit(hosts *gohosts.HostsFile, args []string) error {
+	flags := flag.NewFlagSet("audit", flag.ExitOnError)
+	since := flags.Duration("since", 0, "only show the records of the last duration, such as 24h")
+	user := flags.String("user", "", "only show the records of the username or uid")
+	action := flags.String("action", "", "only show the records of the action: save or restore")
+	hostname := flags.String("hostname", "", "only show the records changing the hostname")
+	asJSON := flags.Bool("json", false, "print the records as JSON lines")
+	flags.Parse(args)
+
+	query := gohosts.AuditQuery{User: *user, Action: gohosts.AuditAction(*action), Hostname: *hostname}
+	if *since > 0 {
+		query.Since = time.Now().Add(-*since)
+	}
+
+	records, err := hosts.AuditLog(query)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		for _, record := range records {
+			if err := encoder.Encode(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, record := range records {
+		fmt.Printf("%s %s %s by %s (uid %s) with %s[%d]", record.Time.Format(time.RFC3339), record.Action, record.Path,
+			record.User, record.UID, record.Process, record.PID)
+		if record.Backup != "" {
+			fmt.Printf(", backup %s", record.Backup)
+		}
+		if record.Restored != "" {
+			fmt.Printf(", restored %s", record.Restored)
+		}
+		fmt.Println()
+		if record.Reason != "" {
+			fmt.Printf("  reason: %s\n", record.Reason)
+		}
+		for _, change := range record.Changes {
+			fmt.Printf("  %s\n", change)
+		}
+	}
+	return nil
+}
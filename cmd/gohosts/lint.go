@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/aymansor/gohosts"
+)
+
+// runLint prints the lint findings of the hosts file, and optionally fixes the fixable ones.
+// It fails if any error remains.
+func runLint(hosts *gohosts.HostsFile, args []string) error {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	fix := flags.Bool("fix", false, "fix the safely fixable findings and save the hosts file")
+	flags.Parse(args)
+
+	if *fix {
+		fixed := gohosts.Fix(hosts)
+		if len(fixed) > 0 {
+			if err := hosts.Save(); err != nil {
+				return err
+			}
+		}
+		for _, finding := range fixed {
+			fmt.Printf("fixed: %s\n", finding)
+		}
+	}
+
+	errors := 0
+	for _, finding := range gohosts.Lint(hosts) {
+		fmt.Println(finding)
+		if finding.Severity == gohosts.SeverityError {
+			errors++
+		}
+	}
+
+	if errors > 0 {
+		return fmt.Errorf("%d errors found", errors)
+	}
+	return nil
+}
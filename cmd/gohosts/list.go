@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aymansor/gohosts"
+)
+
+// runList prints the host entries as a table, with the time remaining for expiring entries.
+// Internationalized hostnames are shown in their Unicode form.
+func runList(hosts *gohosts.HostsFile, args []string) error {
+	flags := flag.NewFlagSet("list", flag.ExitOnError)
+	all := flags.Bool("all", false, "include disabled entries")
+	flags.Parse(args)
+
+	now := time.Now()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "LINE\tIP\tHOSTNAMES\tACTIVE\tEXPIRES\tTAGS\tCOMMENT")
+	for _, entry := range hosts.Entries() {
+		if !entry.Active && !*all {
+			continue
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%t\t%s\t%s\t%s\n",
+			entry.Line, entry.IP, strings.Join(entry.UnicodeHostnames(), " "), entry.Active, timeRemaining(entry, now), entry.FormatTags(), entry.Comment)
+	}
+
+	return w.Flush()
+}
+
+// timeRemaining returns a human readable time remaining before the entry expires.
+func timeRemaining(entry gohosts.HostEntry, now time.Time) string {
+	switch {
+	case entry.Expires.IsZero():
+		return "-"
+	case entry.Expired(now):
+		return "expired"
+	default:
+		return "in " + entry.Expires.Sub(now).Truncate(time.Second).String()
+	}
+}
@@ -0,0 +1,108 @@
+// Command gohosts inspects and manages hosts files.
+//
+// Usage:
+//
+//	gohosts [-file path] [-hostnames policy] [-audit-log path] [-reason text] <command> [arguments]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/aymansor/gohosts"
+)
+
+// command is a gohosts subcommand.
+type command struct {
+	name        string
+	description string
+	run         func(hosts *gohosts.HostsFile, args []string) error
+}
+
+// commands are the available subcommands, in the order they are listed in the usage.
+var commands = []command{
+	{name: "list", description: "list the host entries", run: runList},
+	{name: "lint", description: "check the hosts file for mistakes", run: runLint},
+	{name: "normalize", description: "deduplicate, merge and sort the host entries", run: runNormalize},
+	{name: "assemble", description: "compile the hosts.d fragments into the hosts file", run: runAssemble},
+	{name: "audit", description: "query the audit log of the hosts file writes", run: runAudit},
+}
+
+// options are the global flags.
+type options struct {
+	path     string
+	policy   string
+	auditLog string
+	reason   string
+}
+
+// validators are the hostname validation policies selectable with the -hostnames flag.
+var validators = map[string]gohosts.HostnameValidator{
+	"strict":     gohosts.StrictHostname,
+	"dns":        gohosts.DNSHostname,
+	"permissive": gohosts.PermissiveHostname,
+}
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage: gohosts [-file path] [-hostnames policy] [-audit-log path] [-reason text] <command> [arguments]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(flag.CommandLine.Output(), "  %-10s %s\n", cmd.name, cmd.description)
+	}
+	fmt.Fprintf(flag.CommandLine.Output(), "\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.path, "file", "", "path of the hosts file (default: the system hosts file)")
+	flag.StringVar(&opts.policy, "hostnames", "strict", "hostname validation policy: strict, dns or permissive")
+	flag.StringVar(&opts.auditLog, "audit-log", "", "path of the audit log recording the writes of the hosts file")
+	flag.StringVar(&opts.reason, "reason", "", "reason recorded in the audit log for the write")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	if err := run(opts, flag.Arg(0), flag.Args()[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "gohosts: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run loads the hosts file and runs the named subcommand.
+func run(o options, name string, args []string) error {
+	validator, ok := validators[o.policy]
+	if !ok {
+		return fmt.Errorf("unknown hostname policy %q", o.policy)
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+
+		opts := []gohosts.HostsOption{gohosts.WithHostnameValidator(validator)}
+		if o.path != "" {
+			opts = append(opts, gohosts.WithPath(o.path))
+		}
+		if o.auditLog != "" {
+			opts = append(opts, gohosts.WithAuditLog(o.auditLog), gohosts.WithAuditReason(o.reason))
+		}
+
+		hosts, err := gohosts.New(opts...)
+		if err != nil {
+			return err
+		}
+		if err := hosts.Load(); err != nil {
+			return err
+		}
+
+		return cmd.run(hosts, args)
+	}
+
+	return fmt.Errorf("unknown command %q, run 'gohosts -h' for usage", name)
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/aymansor/gohosts"
+)
+
+// runNormalize normalizes the entries and saves the hosts file. Every step is enabled by default.
+func runNormalize(hosts *gohosts.HostsFile, args []string) error {
+	var opts gohosts.NormalizeOptions
+
+	flags := flag.NewFlagSet("normalize", flag.ExitOnError)
+	flags.BoolVar(&opts.Lowercase, "lowercase", true, "convert the hostnames to lowercase")
+	flags.BoolVar(&opts.MergeByIP, "merge", true, "merge the entries with the same IP address")
+	flags.BoolVar(&opts.DedupeHostnames, "dedupe", true, "remove the hostnames repeated on the same line")
+	flags.BoolVar(&opts.CollapseDisabled, "collapse", true, "remove the duplicate disabled entries")
+	flags.BoolVar(&opts.Sort, "sort", true, "sort the entries by IP address")
+	flags.Parse(args)
+
+	hosts.Normalize(opts)
+	return hosts.Save()
+}
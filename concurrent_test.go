@@ -0,0 +1,183 @@
+package gohosts
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testConcurrentHosts = "# header\n127.0.0.1 localhost\n::1 localhost\n"
+
+func TestConcurrent_AddRemove(t *testing.T) {
+	h, _ := newTestHostsFile(t, testConcurrentHosts)
+
+	const workers = 8
+	const perWorker = 25
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				ip := fmt.Sprintf("10.%d.0.%d", w, i+1)
+				hostname := fmt.Sprintf("host-%d-%d.local", w, i)
+				if err := h.Add(ip, []string{hostname}, ""); err != nil {
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+				if i%2 == 0 {
+					if err := h.Remove(ip, []string{hostname}); err != nil {
+						t.Errorf("unexpected error: %v", err)
+						return
+					}
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	// The two localhost entries and the odd entries of every worker are left
+	expected := 2 + workers*(perWorker/2)
+	if entries := h.Entries(); len(entries) != expected {
+		t.Errorf("expected %d entries, got %d", expected, len(entries))
+	}
+}
+
+func TestConcurrent_ReadersAndWriters(t *testing.T) {
+	h, _ := newTestHostsFile(t, testConcurrentHosts)
+
+	var wg sync.WaitGroup
+	run := func(f func(i int)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				f(i)
+			}
+		}()
+	}
+
+	run(func(i int) {
+		h.Add(fmt.Sprintf("10.0.0.%d", i+1), []string{fmt.Sprintf("web%d.local", i)}, "", WithTags(map[string]string{"owner": "web"}))
+	})
+	run(func(i int) {
+		h.DisableWhere(Tagged("owner", "web"))
+		h.EnableWhere(Tagged("owner", "web"))
+	})
+	run(func(i int) {
+		h.UpdateWhere(HostnameSuffix("local"), func(entry *HostEntry) {
+			entry.Comment = "updated"
+		})
+	})
+	run(func(i int) {
+		if err := h.Save(); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+	run(func(i int) {
+		if err := h.Load(); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+	run(func(i int) {
+		h.Normalize(NormalizeOptions{MergeByIP: true, Sort: true})
+		Fix(h)
+	})
+	run(func(i int) {
+		// Changing a snapshot must not race with the hosts file
+		for _, entry := range h.Entries() {
+			entry.Hostnames[0] = "changed.local"
+			if entry.Tags != nil {
+				entry.Tags["owner"] = "changed"
+			}
+		}
+		h.Filter(IsActive(true))
+		h.FindByTag("owner")
+		h.Blocks()
+		h.Encoding()
+		h.AdditionalContent()
+		Lint(h)
+	})
+	run(func(i int) {
+		var b bytes.Buffer
+		if _, err := h.WriteTo(&b); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if err := h.ExportJSON(&b); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if err := h.ExportCSV(&b); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		h.PruneExpired(time.Now())
+	})
+	wg.Wait()
+
+	for _, entry := range h.Entries() {
+		if entry.Hostnames[0] == "changed.local" || entry.Tags["owner"] == "changed" {
+			t.Errorf("expected the snapshots not to share their entries, got %v", entry)
+		}
+	}
+}
+
+func TestConcurrent_Hooks(t *testing.T) {
+	h, _ := newTestHostsFile(t, testConcurrentHosts, WithAuditLog("/etc/gohosts.log"))
+
+	var mu sync.Mutex
+	saves := 0
+	h.OnAfterSave(func(event HookEvent) error {
+		// After save hooks run once the hosts file is unlocked
+		h.Entries()
+		mu.Lock()
+		saves++
+		mu.Unlock()
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 10; i++ {
+				h.Add(fmt.Sprintf("10.0.%d.%d", w, i+1), []string{fmt.Sprintf("api-%d-%d.local", w, i)}, "")
+				if err := h.SaveWithReason(fmt.Sprintf("worker %d", w)); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+		}(w)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10; i++ {
+			h.OnBeforeSave(func(event HookEvent) error { return nil })
+			if _, err := h.AuditLog(AuditQuery{}); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}
+	}()
+	wg.Wait()
+
+	if saves != 40 {
+		t.Errorf("expected 40 saves, got %d", saves)
+	}
+	records, err := h.AuditLog(AuditQuery{})
+	if err != nil || len(records) != 40 {
+		t.Errorf("expected 40 audit records, got %d, %v", len(records), err)
+	}
+
+	// Each save records the reason it was given, whatever the other goroutines do
+	reasons := make(map[string]int)
+	for _, record := range records {
+		reasons[record.Reason]++
+	}
+	for w := 0; w < 4; w++ {
+		if reason := fmt.Sprintf("worker %d", w); reasons[reason] != 10 {
+			t.Errorf("expected 10 records with reason %q, got %d", reason, reasons[reason])
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package gohosts
+
+import (
+	"fmt"
+	"net/netip"
+)
+
+// ConflictPolicy defines what Add does when a hostname is already mapped to a different IP address.
+type ConflictPolicy int
+
+const (
+	// ConflictAllow adds the entry anyway, the resolver uses whichever mapping comes first.
+	// This is the default policy.
+	ConflictAllow ConflictPolicy = iota
+	// ConflictFail rejects the entry with a *ConflictError.
+	ConflictFail
+	// ConflictReplace removes the hostname from the existing entries before adding the entry.
+	ConflictReplace
+	// ConflictKeepExisting drops the conflicting hostnames from the added entry, the entry is
+	// not added at all if no hostname is left.
+	ConflictKeepExisting
+)
+
+// WithConflictPolicy is a HostsOption that sets the policy used when an added hostname is
+// already mapped to a different IP address.
+func WithConflictPolicy(policy ConflictPolicy) HostsOption {
+	return func(h *HostsFile) {
+		h.conflictPolicy = policy
+	}
+}
+
+// ConflictError is returned by Add when a hostname is already mapped to a different IP address
+// and the ConflictFail policy is used.
+type ConflictError struct {
+	// Hostname is the conflicting hostname.
+	Hostname string
+	// IP is the IP address the hostname was being added for.
+	IP string
+	// ExistingIP is the IP address the hostname is already mapped to.
+	ExistingIP string
+	// Line is the line number of the existing entry, 0 if it was not read from the file.
+	Line int
+}
+
+// Error implements the error interface.
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("hostname %s is already mapped to %s on line %d, cannot map it to %s", e.Hostname, e.ExistingIP, e.Line, e.IP)
+}
+
+// findConflict returns the first active entry mapping the hostname to an IP address other than
+// the provided one, in the same address family. It returns -1 if there is none.
+func findConflict(entries []HostEntry, ip, hostname string) int {
+	parsed, _ := netip.ParseAddr(ip)
+	for i, entry := range entries {
+		if !entry.Active {
+			continue
+		}
+
+		existing := entry.Addr()
+		if !existing.IsValid() || existing == parsed || !sameFamily(existing, parsed) {
+			continue
+		}
+
+		for _, name := range entry.Hostnames {
+			if equalHostnames(name, hostname) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// resolveConflicts applies the conflict policy to the hostnames about to be added for the IP address.
+// It returns the hostnames to add, along with the entries to keep.
+func (h *HostsFile) resolveConflicts(ip string, hostnames []string) ([]string, []HostEntry, error) {
+	entries := h.entries
+	if h.conflictPolicy == ConflictAllow {
+		return hostnames, entries, nil
+	}
+
+	var kept []string
+	for _, hostname := range hostnames {
+		i := findConflict(entries, ip, hostname)
+		if i == -1 {
+			kept = append(kept, hostname)
+			continue
+		}
+
+		switch h.conflictPolicy {
+		case ConflictFail:
+			return nil, nil, &ConflictError{Hostname: hostname, IP: ip, ExistingIP: entries[i].IP, Line: entries[i].Line}
+		case ConflictKeepExisting:
+			continue
+		case ConflictReplace:
+			kept = append(kept, hostname)
+			// Remove the hostname from every conflicting entry, dropping the entries left empty
+			var replaced []HostEntry
+			for _, entry := range entries {
+				if findConflict([]HostEntry{entry}, ip, hostname) != -1 {
+					entry.Hostnames = removeHostname(entry.Hostnames, hostname)
+					if len(entry.Hostnames) == 0 {
+						continue
+					}
+				}
+				replaced = append(replaced, entry)
+			}
+			entries = replaced
+		}
+	}
+
+	return kept, entries, nil
+}
+
+// removeHostname removes the hostname from the slice, compared with equalHostnames.
+func removeHostname(hostnames []string, hostname string) []string {
+	var result []string
+	for _, name := range hostnames {
+		if !equalHostnames(name, hostname) {
+			result = append(result, name)
+		}
+	}
+	return result
+}
@@ -0,0 +1,101 @@
+package gohosts
+
+import (
+	"errors"
+	"testing"
+)
+
+const testConflictHosts = `127.0.0.1 localhost
+10.0.0.1 api.local web.local
+# 10.0.0.3 db.local
+`
+
+func TestAdd_ConflictAllow(t *testing.T) {
+	h, _ := newTestHostsFile(t, testConflictHosts, WithConflictPolicy(ConflictAllow))
+
+	err := h.Add("10.0.0.2", []string{"api.local"}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(h.entries) != 4 {
+		t.Errorf("expected 4 entries, got %d", len(h.entries))
+	}
+}
+
+func TestAdd_ConflictFail(t *testing.T) {
+	h, _ := newTestHostsFile(t, testConflictHosts, WithConflictPolicy(ConflictFail))
+
+	err := h.Add("10.0.0.2", []string{"new.local", "API.local"}, "")
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("expected a ConflictError, got %v", err)
+	}
+	expected := ConflictError{Hostname: "api.local", IP: "10.0.0.2", ExistingIP: "10.0.0.1", Line: 2}
+	if *conflict != expected {
+		t.Errorf("expected %v, got %v", expected, *conflict)
+	}
+	if len(h.entries) != 3 {
+		t.Errorf("expected the entries to be unchanged, got %v", h.entries)
+	}
+
+	// AddBatch returns the same error
+	err = h.AddBatch(HostEntry{IP: "10.0.0.2", Hostnames: []string{"web.local"}})
+	if !errors.As(err, &conflict) || conflict.Hostname != "web.local" {
+		t.Errorf("expected a ConflictError for web.local, got %v", err)
+	}
+
+	// Disabled entries, the same IP address and other address families are not conflicts
+	err = h.AddBatch(
+		HostEntry{IP: "10.0.0.2", Hostnames: []string{"db.local"}},
+		HostEntry{IP: "10.0.0.1", Hostnames: []string{"api.local"}},
+		HostEntry{IP: "::1", Hostnames: []string{"localhost"}},
+	)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestAdd_ConflictReplace(t *testing.T) {
+	h, _ := newTestHostsFile(t, testConflictHosts, WithConflictPolicy(ConflictReplace))
+	h.entries = append(h.entries, HostEntry{Line: 4, IP: "10.0.0.4", Hostnames: []string{"api.local"}, Active: true})
+
+	err := h.Add("10.0.0.2", []string{"api.local"}, "moved")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []HostEntry{
+		{IP: "127.0.0.1", Hostnames: []string{"localhost"}, Active: true},
+		{IP: "10.0.0.1", Hostnames: []string{"web.local"}, Active: true},
+		{IP: "10.0.0.3", Hostnames: []string{"db.local"}, Active: false},
+		{IP: "10.0.0.2", Hostnames: []string{"api.local"}, Comment: "moved", Active: true},
+	}
+	if !Equal(h.entries, expected) {
+		t.Errorf("Entries do not match expected entries. \nGot: %v, \nExpected: %v", h.entries, expected)
+	}
+}
+
+func TestAdd_ConflictKeepExisting(t *testing.T) {
+	h, _ := newTestHostsFile(t, testConflictHosts, WithConflictPolicy(ConflictKeepExisting))
+
+	err := h.Add("10.0.0.2", []string{"api.local", "new.local"}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Nothing is added when every hostname conflicts
+	err = h.Add("10.0.0.2", []string{"web.local"}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []HostEntry{
+		{IP: "127.0.0.1", Hostnames: []string{"localhost"}, Active: true},
+		{IP: "10.0.0.1", Hostnames: []string{"api.local", "web.local"}, Active: true},
+		{IP: "10.0.0.3", Hostnames: []string{"db.local"}, Active: false},
+		{IP: "10.0.0.2", Hostnames: []string{"new.local"}, Active: true},
+	}
+	if !Equal(h.entries, expected) {
+		t.Errorf("Entries do not match expected entries. \nGot: %v, \nExpected: %v", h.entries, expected)
+	}
+}
@@ -0,0 +1,75 @@
+package gohosts
+
+// cloneChanges returns a copy of the changes not sharing any hostnames or tags with them.
+func cloneChanges(changes []Change) []Change {
+	if changes == nil {
+		return nil
+	}
+	cloned := make([]Change, len(changes))
+	for i, change := range changes {
+		cloned[i] = Change{Action: change.Action, Before: cloneEntry(change.Before), After: cloneEntry(change.After)}
+	}
+	return cloned
+}
+
+// changeAction returns the action changing the before entry into the after entry, and false if
+// they are equal. Entries differing only by their active state are enabled or disabled.
+func changeAction(before, after HostEntry) (ChangeAction, bool) {
+	toggled := before
+	toggled.Active = after.Active
+	switch {
+	case before.Equal(after):
+		return 0, false
+	case !toggled.Equal(after):
+		// Something other than the active state differs
+		return ActionUpdate, true
+	case after.Active:
+		return ActionEnable, true
+	default:
+		return ActionDisable, true
+	}
+}
+
+// Diff returns the changes turning the before entries into the after entries. Entries are
+// matched by block and IP address, in order, so the nth entry of an IP address in a block
+// is compared with the nth entry of the same IP address in the same block.
+// Adds and updates come in the order of the after entries, followed by the removes.
+func Diff(before, after []HostEntry) []Change {
+	type key struct {
+		block string
+		ip    string
+	}
+
+	remaining := make(map[key][]HostEntry)
+	var order []key
+	for _, entry := range before {
+		k := key{block: entry.Block, ip: entry.IP}
+		if _, ok := remaining[k]; !ok {
+			order = append(order, k)
+		}
+		remaining[k] = append(remaining[k], entry)
+	}
+
+	var changes []Change
+	for _, entry := range after {
+		k := key{block: entry.Block, ip: entry.IP}
+		if len(remaining[k]) == 0 {
+			changes = append(changes, Change{Action: ActionAdd, After: entry})
+			continue
+		}
+
+		previous := remaining[k][0]
+		remaining[k] = remaining[k][1:]
+		if action, changed := changeAction(previous, entry); changed {
+			changes = append(changes, Change{Action: action, Before: previous, After: entry})
+		}
+	}
+
+	for _, k := range order {
+		for _, entry := range remaining[k] {
+			changes = append(changes, Change{Action: ActionRemove, Before: entry})
+		}
+	}
+
+	return changes
+}
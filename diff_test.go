@@ -0,0 +1,85 @@
+package gohosts
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	before := []HostEntry{
+		{IP: "127.0.0.1", Hostnames: []string{"localhost"}, Active: true},
+		{IP: "10.0.0.1", Hostnames: []string{"api.local"}, Active: true},
+		{IP: "10.0.0.2", Hostnames: []string{"web.local"}, Active: true},
+		{IP: "10.0.0.3", Hostnames: []string{"old.local"}, Active: true},
+		{IP: "10.0.0.4", Hostnames: []string{"cache.local"}, Active: false},
+		{IP: "10.0.0.5", Hostnames: []string{"db.local"}, Active: true, Block: "dev"},
+	}
+	after := []HostEntry{
+		{IP: "127.0.0.1", Hostnames: []string{"localhost"}, Active: true, Line: 3},
+		{IP: "10.0.0.1", Hostnames: []string{"api.local", "www.local"}, Active: true},
+		{IP: "10.0.0.2", Hostnames: []string{"web.local"}, Active: false},
+		{IP: "10.0.0.4", Hostnames: []string{"cache.local"}, Active: true},
+		{IP: "10.0.0.5", Hostnames: []string{"db.local"}, Active: true},
+		{IP: "10.0.0.6", Hostnames: []string{"new.local"}, Active: true},
+	}
+
+	changes := Diff(before, after)
+
+	expected := []Change{
+		{Action: ActionUpdate, Before: before[1], After: after[1]},
+		{Action: ActionDisable, Before: before[2], After: after[2]},
+		{Action: ActionEnable, Before: before[4], After: after[3]},
+		// Entries of another block are different entries
+		{Action: ActionAdd, After: after[4]},
+		{Action: ActionAdd, After: after[5]},
+		{Action: ActionRemove, Before: before[3]},
+		{Action: ActionRemove, Before: before[5]},
+	}
+
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d changes, got %d: %v", len(expected), len(changes), changes)
+	}
+	for i, change := range changes {
+		if change.Action != expected[i].Action || !change.Before.Equal(expected[i].Before) || !change.After.Equal(expected[i].After) {
+			t.Errorf("change %d: expected %v, got %v", i, expected[i], change)
+		}
+	}
+
+	if changes := Diff(before, before); len(changes) != 0 {
+		t.Errorf("expected no changes between equal entries, got %v", changes)
+	}
+}
+
+func TestChangeAction_Text(t *testing.T) {
+	for action := ActionAdd; action <= ActionDisable; action++ {
+		text, err := action.MarshalText()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var decoded ChangeAction
+		if err := decoded.UnmarshalText(text); err != nil || decoded != action {
+			t.Errorf("expected %v, got %v, %v", action, decoded, err)
+		}
+	}
+
+	var action ChangeAction
+	if err := action.UnmarshalText([]byte("rename")); err == nil {
+		t.Errorf("Expected an error for an invalid change action")
+	}
+}
+
+func TestChange_MarshalJSON(t *testing.T) {
+	change := Change{Action: ActionAdd, After: HostEntry{IP: "10.0.0.1", Hostnames: []string{"api.local"}, Active: true}}
+
+	data, err := json.Marshal(change)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The zero before entry and expiry are left out
+	expected := `{"action":"add","after":{"line":0,"ip":"10.0.0.1","hostnames":["api.local"],"active":true,"block":""}}`
+	if string(data) != expected {
+		t.Errorf("expected %s, got %s", expected, data)
+	}
+}
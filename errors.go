@@ -0,0 +1,50 @@
+package gohosts
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrEntryNotFound is returned when no host entry matches the IP address and hostnames of an operation.
+	ErrEntryNotFound = errors.New("host entry not found")
+	// ErrInvalidIP is returned for an IP address that cannot be parsed or is rejected by the
+	// IPv4-mapped policy.
+	ErrInvalidIP = errors.New("invalid IP address")
+	// ErrInvalidHostname is returned for a hostname rejected by the hostname validator.
+	ErrInvalidHostname = errors.New("invalid hostname")
+	// ErrInvalidBlock is returned for a block name that cannot be written in a block marker.
+	ErrInvalidBlock = errors.New("invalid block name")
+	// ErrInvalidComment is returned for an entry comment that would not be read back the same,
+	// such as a comment spanning several lines.
+	ErrInvalidComment = errors.New("invalid comment")
+	// ErrNoHostnames is returned for an entry without hostnames.
+	ErrNoHostnames = errors.New("no hostnames provided")
+	// ErrNoBackups is returned when there is no backup, or not enough backups, to restore.
+	ErrNoBackups = errors.New("no backup to restore")
+	// ErrProfileNotFound is returned when loading or activating a profile that does not exist.
+	ErrProfileNotFound = errors.New("profile not found")
+	// ErrAuditLog is returned when the hosts file was written but the audit log could not be.
+	ErrAuditLog = errors.New("failed to write the audit log")
+	// ErrNoPath is returned when loading or saving a HostsFile without path.
+	ErrNoPath = errors.New("hosts file has no path")
+)
+
+// PathError records an error and the operation and path of the file that caused it, the hosts
+// file or one of its backups. Err is often an fs.PathError, or fs.ErrNotExist for a missing hosts file.
+type PathError struct {
+	// Op is the failed operation, such as "load", "save", "backup" or "restore".
+	Op   string
+	Path string
+	Err  error
+}
+
+// Error implements the error interface.
+func (e *PathError) Error() string {
+	return fmt.Sprintf("failed to %s %s: %v", e.Op, e.Path, e.Err)
+}
+
+// Unwrap returns the underlying error, for errors.Is and errors.As.
+func (e *PathError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,104 @@
+package gohosts
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+	h, _ := newTestHostsFile(t, "127.0.0.1 localhost\n")
+
+	tests := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{"add invalid IP", h.Add("invalid", []string{"api.local"}, ""), ErrInvalidIP},
+		{"add invalid hostname", h.Add("10.0.0.1", []string{"api local"}, ""), ErrInvalidHostname},
+		{"add no hostnames", h.Add("10.0.0.1", nil, ""), ErrNoHostnames},
+		{"remove invalid IP", h.Remove("invalid", []string{"localhost"}), ErrInvalidIP},
+		{"remove missing entry", h.Remove("10.0.0.9", []string{"missing.local"}), ErrEntryNotFound},
+		{"restore without backup", h.RestoreBackup(), ErrNoBackups},
+		{"save without path", (&HostsFile{}).Save(), ErrNoPath},
+		{"load without path", (&HostsFile{}).Load(), ErrNoPath},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if !errors.Is(test.err, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, test.err)
+			}
+		})
+	}
+}
+
+func TestErrors_Wrapped(t *testing.T) {
+	h, m := newTestHostsFile(t, "127.0.0.1 localhost\n")
+	if err := m.WriteFile("/profiles/broken.json", []byte(`[{"ip":"invalid","hostnames":["api.local"]}]`), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, assembleErr := h.Assemble("/missing")
+
+	// The errors of the profiles, fragments and imports keep their cause
+	tests := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{"activate missing profile", h.ActivateProfile("missing"), ErrProfileNotFound},
+		{"activate invalid profile", h.ActivateProfile("broken"), ErrInvalidIP},
+		{"assemble missing directory", assembleErr, fs.ErrNotExist},
+		{"import invalid entry", h.ImportYAML(strings.NewReader("entries:\n  - ip: 10.0.0.1\n    hostnames: [bad_name!]\n")), ErrInvalidHostname},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if !errors.Is(test.err, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, test.err)
+			}
+		})
+	}
+
+	var syntaxErr *json.SyntaxError
+	if err := h.ImportJSON(strings.NewReader("{]")); !errors.As(err, &syntaxErr) {
+		t.Errorf("expected the JSON decoding error to be wrapped, got %v", err)
+	}
+}
+
+func TestPathError(t *testing.T) {
+	h, m := newTestHostsFile(t, "127.0.0.1 localhost\n")
+	if err := m.Remove("/etc/hosts"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err := h.Load()
+	var pathErr *PathError
+	if !errors.As(err, &pathErr) || pathErr.Op != "load" || pathErr.Path != "/etc/hosts" {
+		t.Errorf("expected a load PathError, got %v", err)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+
+	err = h.Save()
+	if !errors.As(err, &pathErr) || pathErr.Op != "save" || !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected a save PathError, got %v", err)
+	}
+
+	_, err = New(WithFS(m), WithPath("/etc/missing"))
+	if !errors.As(err, &pathErr) || pathErr.Path != "/etc/missing" || !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected an open PathError, got %v", err)
+	}
+
+	err = h.CreateBackup()
+	if !errors.As(err, &pathErr) || pathErr.Op != "backup" {
+		t.Errorf("expected a backup PathError, got %v", err)
+	}
+	if err.Error() != "failed to backup /etc/hosts: open /etc/hosts: file does not exist" {
+		t.Errorf("unexpected message: %s", err)
+	}
+}
@@ -26,7 +26,7 @@ func main() {
 	}
 
 	// Access the hosts entries
-	for _, host := range hosts.Entries {
+	for _, host := range hosts.Entries() {
 		fmt.Printf("IP: %s, Hostnames: %v, Comment: %s\n", host.IP, host.Hostnames, host.Comment)
 	}
 
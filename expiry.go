@@ -0,0 +1,113 @@
+package gohosts
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	// ExpiresKey is the key of the structured comment holding the expiry of an entry,
+	// written as "# gohosts:expires=2026-10-20T12:00Z".
+	ExpiresKey = "gohosts:expires"
+
+	// expiresLayout is the layout used to write expiries without seconds.
+	expiresLayout = "2006-01-02T15:04Z07:00"
+)
+
+// EntryOption is a functional option for configuring a HostEntry added with Add.
+type EntryOption func(*HostEntry)
+
+// WithExpiry is an EntryOption that sets the time after which the entry is expired.
+func WithExpiry(expires time.Time) EntryOption {
+	return func(e *HostEntry) {
+		e.Expires = expires
+	}
+}
+
+// WithAutoPrune is a HostsOption that drops expired entries when the hosts file is loaded and saved.
+func WithAutoPrune() HostsOption {
+	return func(h *HostsFile) {
+		h.autoPrune = true
+	}
+}
+
+// Expired reports whether the entry has an expiry and it is not after now.
+func (e HostEntry) Expired(now time.Time) bool {
+	return !e.Expires.IsZero() && !now.Before(e.Expires)
+}
+
+// PruneExpired removes the expired entries and returns the number of removed entries.
+func (h *HostsFile) PruneExpired(now time.Time) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	defer h.edit("PruneExpired")()
+
+	return h.pruneExpired(now)
+}
+
+// autoPruneExpired removes the expired entries before writing the hosts file if WithAutoPrune is
+// set, and records the edit in the history.
+func (h *HostsFile) autoPruneExpired() {
+	if !h.autoPrune {
+		return
+	}
+
+	done := h.edit("PruneExpired")
+	h.pruneExpired(h.now())
+	done()
+}
+
+// pruneExpired removes the expired entries, see PruneExpired.
+func (h *HostsFile) pruneExpired(now time.Time) int {
+	var entries []HostEntry
+	for _, entry := range h.entries {
+		if !entry.Expired(now) {
+			entries = append(entries, entry)
+		}
+	}
+
+	pruned := len(h.entries) - len(entries)
+	h.entries = entries
+	return pruned
+}
+
+// formatExpires returns the value written for the expiry, seconds are omitted when they are zero.
+func formatExpires(expires time.Time) string {
+	expires = expires.UTC()
+	if expires.Second() == 0 && expires.Nanosecond() == 0 {
+		return expires.Format(expiresLayout)
+	}
+	return expires.Format(time.RFC3339)
+}
+
+// parseExpires parses an expiry written by formatExpires.
+func parseExpires(value string) (time.Time, bool) {
+	for _, layout := range []string{expiresLayout, time.RFC3339} {
+		if expires, err := time.Parse(layout, value); err == nil {
+			return expires, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// extractExpiry removes the expiry from the provided comment, and returns the remaining comment
+// with the expiry. If the comment has no valid expiry it is returned unchanged.
+func extractExpiry(comment string) (string, time.Time) {
+	var expires time.Time
+	var rest []string
+
+	for _, field := range strings.Fields(comment) {
+		if value, ok := strings.CutPrefix(field, ExpiresKey+"="); ok {
+			if parsed, ok := parseExpires(value); ok {
+				expires = parsed
+				continue
+			}
+		}
+		rest = append(rest, field)
+	}
+
+	if expires.IsZero() {
+		return comment, expires
+	}
+	return strings.Join(rest, " "), expires
+}
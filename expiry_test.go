@@ -0,0 +1,127 @@
+package gohosts
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseHosts_Expiry(t *testing.T) {
+	h := &HostsFile{}
+
+	lines := []string{
+		"10.0.0.1 debug.local # debugging gohosts:expires=2026-10-20T12:00Z",
+		"10.0.0.2 other.local # gohosts:expires=2026-10-20T12:00:30+02:00",
+		"10.0.0.3 broken.local # gohosts:expires=tomorrow",
+	}
+
+	entries, err := h.parseHosts(lines)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []HostEntry{
+		{IP: "10.0.0.1", Hostnames: []string{"debug.local"}, Comment: "debugging", Active: true, Expires: time.Date(2026, 10, 20, 12, 0, 0, 0, time.UTC)},
+		{IP: "10.0.0.2", Hostnames: []string{"other.local"}, Active: true, Expires: time.Date(2026, 10, 20, 10, 0, 30, 0, time.UTC)},
+		{IP: "10.0.0.3", Hostnames: []string{"broken.local"}, Comment: "gohosts:expires=tomorrow", Active: true},
+	}
+
+	if !Equal(entries, expected) {
+		t.Errorf("Entries do not match expected entries. \nGot: %v, \nExpected: %v", entries, expected)
+	}
+}
+
+func TestAdd_WithExpiry(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "hosts_test")
+	if err != nil {
+		t.Fatalf("Error creating temporary file: %v", err)
+	}
+	defer tempFile.Close()
+	defer os.Remove(tempFile.Name())
+
+	h, err := New(WithPath(tempFile.Name()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expires := time.Date(2026, 10, 20, 12, 0, 0, 0, time.UTC)
+	err = h.Add("10.0.0.1", []string{"debug.local"}, "debugging", WithExpiry(expires))
+	if err != nil {
+		t.Fatalf("Error adding entry: %v", err)
+	}
+
+	err = h.Save()
+	if err != nil {
+		t.Fatalf("failed to save hosts file: %v", err)
+	}
+
+	content, err := os.ReadFile(tempFile.Name())
+	if err != nil {
+		t.Fatalf("failed to read hosts file: %v", err)
+	}
+	if !strings.Contains(string(content), "# debugging gohosts:expires=2026-10-20T12:00Z\n") {
+		t.Errorf("expected the expiry to be written, got:\n%s", content)
+	}
+
+	err = h.Load()
+	if err != nil {
+		t.Fatalf("failed to load hosts file: %v", err)
+	}
+	if len(h.entries) != 1 || !h.entries[0].Expires.Equal(expires) || h.entries[0].Comment != "debugging" {
+		t.Errorf("expected the expiry to be loaded, got %v", h.entries)
+	}
+}
+
+func TestPruneExpired(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	h := &HostsFile{
+		entries: []HostEntry{
+			{IP: "10.0.0.1", Hostnames: []string{"expired.local"}, Active: true, Expires: now.Add(-time.Minute)},
+			{IP: "10.0.0.2", Hostnames: []string{"valid.local"}, Active: true, Expires: now.Add(time.Hour)},
+			{IP: "10.0.0.3", Hostnames: []string{"permanent.local"}, Active: true},
+			{IP: "10.0.0.4", Hostnames: []string{"now.local"}, Active: true, Expires: now},
+		},
+	}
+
+	pruned := h.PruneExpired(now)
+	if pruned != 2 {
+		t.Errorf("expected 2 pruned entries, got %d", pruned)
+	}
+
+	expected := []HostEntry{
+		{IP: "10.0.0.2", Hostnames: []string{"valid.local"}, Active: true, Expires: now.Add(time.Hour)},
+		{IP: "10.0.0.3", Hostnames: []string{"permanent.local"}, Active: true},
+	}
+	if !Equal(h.entries, expected) {
+		t.Errorf("Entries do not match expected entries. \nGot: %v, \nExpected: %v", h.entries, expected)
+	}
+}
+
+func TestLoad_AutoPrune(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "hosts_test")
+	if err != nil {
+		t.Fatalf("Error creating temporary file: %v", err)
+	}
+	defer tempFile.Close()
+	defer os.Remove(tempFile.Name())
+
+	_, err = tempFile.WriteString("10.0.0.1 expired.local # gohosts:expires=2000-01-01T00:00Z\n10.0.0.2 valid.local\n")
+	if err != nil {
+		t.Fatalf("failed to write to temporary file: %v", err)
+	}
+
+	h, err := New(WithPath(tempFile.Name()), WithAutoPrune())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = h.Load()
+	if err != nil {
+		t.Fatalf("failed to load hosts file: %v", err)
+	}
+	if len(h.entries) != 1 || h.entries[0].IP != "10.0.0.2" {
+		t.Errorf("expected only the valid entry, got %v", h.entries)
+	}
+}
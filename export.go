@@ -0,0 +1,282 @@
+package gohosts
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// csvHeader is the header row written by ExportCSV and expected by ImportCSV.
+var csvHeader = []string{"line", "ip", "hostnames", "comment", "tags", "active", "expires", "block"}
+
+// hostsDocument is the serialized form of a HostsFile used by the JSON and YAML formats.
+type hostsDocument struct {
+	AdditionalContent string      `json:"additional_content" yaml:"additional_content"`
+	Entries           []HostEntry `json:"entries" yaml:"entries"`
+}
+
+// document returns the serializable form of the hosts file.
+func (h *HostsFile) document() hostsDocument {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	entries := cloneEntries(h.entries)
+	if entries == nil {
+		entries = []HostEntry{}
+	}
+
+	return hostsDocument{
+		AdditionalContent: h.additionalContent,
+		Entries:           entries,
+	}
+}
+
+// setDocument validates the provided document and replaces the content of the hosts file with it,
+// recording the edit under the name of the provided operation.
+func (h *HostsFile) setDocument(operation string, doc hostsDocument) error {
+	for i := range doc.Entries {
+		if err := h.validateImportedEntry(&doc.Entries[i]); err != nil {
+			return err
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	defer h.edit(operation)()
+
+	h.additionalContent = doc.AdditionalContent
+	h.entries = doc.Entries
+	return nil
+}
+
+// validateImportedEntry checks that an imported entry can be written back to a hosts file and read
+// back the same, and canonicalizes its IP address and hostnames the same way Add does.
+func (h *HostsFile) validateImportedEntry(entry *HostEntry) error {
+	ip, ok := h.canonicalIP(entry.IP)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrInvalidIP, entry.IP)
+	}
+	if len(entry.Hostnames) == 0 {
+		return fmt.Errorf("%w for IP address: %s", ErrNoHostnames, entry.IP)
+	}
+	if err := validateTags(entry.Tags); err != nil {
+		return err
+	}
+	if err := validateBlock(entry.Block); err != nil {
+		return err
+	}
+	if err := validateComment(entry.Comment); err != nil {
+		return err
+	}
+
+	// The hostnames may be shared with a snapshot, normalizeHostnames returns new ones rather
+	// than changing them in place
+	hostnames, err := h.normalizeHostnames(entry.Hostnames)
+	if err != nil {
+		return err
+	}
+	entry.IP = ip
+	entry.Hostnames = hostnames
+	return nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+// Entries without an "active" field are considered active.
+func (e *HostEntry) UnmarshalJSON(data []byte) error {
+	type entry HostEntry
+	decoded := entry{Active: true}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	*e = HostEntry(decoded)
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+// Entries without an "active" field are considered active, as with UnmarshalJSON.
+func (e *HostEntry) UnmarshalYAML(value *yaml.Node) error {
+	type entry HostEntry
+	decoded := entry{Active: true}
+	if err := value.Decode(&decoded); err != nil {
+		return err
+	}
+
+	*e = HostEntry(decoded)
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (h *HostsFile) MarshalJSON() ([]byte, error) {
+	return json.Marshal(h.document())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+// It replaces the entries and additional content of the hosts file, the path is left untouched.
+func (h *HostsFile) UnmarshalJSON(data []byte) error {
+	var doc hostsDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	return h.setDocument("UnmarshalJSON", doc)
+}
+
+// ExportJSON writes the hosts file as an indented JSON document.
+func (h *HostsFile) ExportJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(h.document())
+}
+
+// ImportJSON reads a JSON document written by ExportJSON and replaces the content of the hosts file.
+func (h *HostsFile) ImportJSON(r io.Reader) error {
+	var doc hostsDocument
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return fmt.Errorf("failed to decode JSON: %w", err)
+	}
+
+	return h.setDocument("ImportJSON", doc)
+}
+
+// ExportYAML writes the hosts file as a YAML document.
+func (h *HostsFile) ExportYAML(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(h.document()); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// ImportYAML reads a YAML document written by ExportYAML and replaces the content of the hosts file.
+func (h *HostsFile) ImportYAML(r io.Reader) error {
+	var doc hostsDocument
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+		return fmt.Errorf("failed to decode YAML: %w", err)
+	}
+
+	return h.setDocument("ImportYAML", doc)
+}
+
+// ExportCSV writes the entries of the hosts file as CSV, one entry per row.
+// Hostnames and key=value tags are separated by spaces, and expiries are written in RFC 3339 format.
+// The additional content is not exported.
+func (h *HostsFile) ExportCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, entry := range h.Entries() {
+		var expires string
+		if !entry.Expires.IsZero() {
+			expires = entry.Expires.Format(time.RFC3339)
+		}
+
+		record := []string{
+			strconv.Itoa(entry.Line),
+			entry.IP,
+			strings.Join(entry.Hostnames, " "),
+			entry.Comment,
+			formatTags(entry.Tags),
+			strconv.FormatBool(entry.Active),
+			expires,
+			entry.Block,
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// ImportCSV reads CSV written by ExportCSV and replaces the entries of the hosts file.
+// Columns are matched by the header row, so they may appear in any order.
+func (h *HostsFile) ImportCSV(r io.Reader) error {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return fmt.Errorf("failed to decode CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("failed to decode CSV: missing header")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, name := range []string{"ip", "hostnames"} {
+		if _, ok := columns[name]; !ok {
+			return fmt.Errorf("failed to decode CSV: missing %q column", name)
+		}
+	}
+
+	// field returns the value of the named column, or an empty string if the column is missing
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return record[i]
+	}
+
+	entries := make([]HostEntry, 0, len(records)-1)
+	for i, record := range records[1:] {
+		entry := HostEntry{
+			IP:        field(record, "ip"),
+			Hostnames: strings.Fields(field(record, "hostnames")),
+			Comment:   field(record, "comment"),
+			Active:    true,
+			Block:     field(record, "block"),
+		}
+
+		if tags := field(record, "tags"); tags != "" {
+			var rest string
+			rest, entry.Tags = extractTags(tags)
+			if rest != "" {
+				return fmt.Errorf("invalid tags on row %d: %s", i+2, tags)
+			}
+		}
+		if line := field(record, "line"); line != "" {
+			entry.Line, err = strconv.Atoi(line)
+			if err != nil {
+				return fmt.Errorf("invalid line number on row %d: %s", i+2, line)
+			}
+		}
+		if active := field(record, "active"); active != "" {
+			entry.Active, err = strconv.ParseBool(active)
+			if err != nil {
+				return fmt.Errorf("invalid active state on row %d: %s", i+2, active)
+			}
+		}
+
+		if expires := field(record, "expires"); expires != "" {
+			entry.Expires, err = time.Parse(time.RFC3339, expires)
+			if err != nil {
+				return fmt.Errorf("invalid expiry on row %d: %s", i+2, expires)
+			}
+		}
+
+		if err := h.validateImportedEntry(&entry); err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	defer h.edit("ImportCSV")()
+
+	h.entries = entries
+	return nil
+}
@@ -0,0 +1,188 @@
+package gohosts
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testExportHosts = "# header comment\n127.0.0.1 localhost # loopback\n# 10.0.0.1 disabled.local\n# gohosts:begin dev\n10.0.0.2 api.dev.local web.dev.local # team=dev owner=web gohosts:expires=2026-10-20T12:00Z\n# gohosts:end dev\n"
+
+func assertRoundTrip(t *testing.T, original, imported *HostsFile) {
+	t.Helper()
+
+	if !Equal(imported.entries, original.entries) {
+		t.Errorf("Entries do not match expected entries. \nGot: %v, \nExpected: %v", imported.entries, original.entries)
+	}
+	for i := range original.entries {
+		if imported.entries[i].Line != original.entries[i].Line {
+			t.Errorf("expected line %d, got %d", original.entries[i].Line, imported.entries[i].Line)
+		}
+	}
+}
+
+func TestExportJSON(t *testing.T) {
+	h, _ := newTestHostsFile(t, testExportHosts)
+
+	var buf bytes.Buffer
+	if err := h.ExportJSON(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var doc struct {
+		Entries []map[string]interface{} `json:"entries"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(doc.Entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(doc.Entries))
+	}
+	if doc.Entries[2]["line"] != float64(5) || doc.Entries[2]["block"] != "dev" || doc.Entries[2]["active"] != true {
+		t.Errorf("unexpected entry: %v", doc.Entries[2])
+	}
+	if doc.Entries[1]["active"] != false {
+		t.Errorf("expected inactive entry, got %v", doc.Entries[1])
+	}
+
+	imported := &HostsFile{}
+	if err := imported.ImportJSON(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertRoundTrip(t, h, imported)
+
+	if imported.additionalContent != h.additionalContent {
+		t.Errorf("expected additional content %q, got %q", h.additionalContent, imported.additionalContent)
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	h, _ := newTestHostsFile(t, testExportHosts)
+
+	data, err := json.Marshal(h)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	imported := &HostsFile{}
+	if err := json.Unmarshal(data, imported); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertRoundTrip(t, h, imported)
+
+	// Test unmarshalling an entry with an invalid IP address
+	err = json.Unmarshal([]byte(`{"entries":[{"ip":"invalid_ip","hostnames":["localhost"]}]}`), imported)
+	if err == nil {
+		t.Error("Expected an error for entry with an invalid IP address")
+	}
+}
+
+func TestExportYAML(t *testing.T) {
+	h, _ := newTestHostsFile(t, testExportHosts)
+
+	var buf bytes.Buffer
+	if err := h.ExportYAML(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "block: dev") {
+		t.Errorf("expected block membership in output, got:\n%s", buf.String())
+	}
+
+	imported := &HostsFile{}
+	if err := imported.ImportYAML(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertRoundTrip(t, h, imported)
+
+	if imported.additionalContent != h.additionalContent {
+		t.Errorf("expected additional content %q, got %q", h.additionalContent, imported.additionalContent)
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	h, _ := newTestHostsFile(t, testExportHosts)
+
+	var buf bytes.Buffer
+	if err := h.ExportCSV(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "line,ip,hostnames,comment,tags,active,expires,block\n" +
+		"2,127.0.0.1,localhost,loopback,,true,,\n" +
+		"3,10.0.0.1,disabled.local,,,false,,\n" +
+		"5,10.0.0.2,api.dev.local web.dev.local,,owner=web team=dev,true,2026-10-20T12:00:00Z,dev\n"
+	if buf.String() != expected {
+		t.Errorf("\n%s\n\n%s", expected, buf.String())
+	}
+
+	imported := &HostsFile{}
+	if err := imported.ImportCSV(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertRoundTrip(t, h, imported)
+}
+
+func TestImportCSV_Invalid(t *testing.T) {
+	h := &HostsFile{}
+
+	// Test importing without the required columns
+	err := h.ImportCSV(strings.NewReader("line,comment\n1,test\n"))
+	if err == nil {
+		t.Error("Expected an error for missing columns")
+	}
+
+	// Test importing an invalid active state
+	err = h.ImportCSV(strings.NewReader("ip,hostnames,active\n127.0.0.1,localhost,maybe\n"))
+	if err == nil {
+		t.Error("Expected an error for invalid active state")
+	}
+}
+
+func TestImportJSON_Invalid(t *testing.T) {
+	h := &HostsFile{}
+
+	// Test importing hostnames that would not be read back the same
+	err := h.ImportJSON(strings.NewReader(`{"entries":[{"ip":"10.0.0.1","hostnames":["ok.local","bad_name!","#x"]}]}`))
+	if !errors.Is(err, ErrInvalidHostname) {
+		t.Errorf("Expected ErrInvalidHostname for invalid hostnames, got %v", err)
+	}
+
+	// Test importing a block name the block markers cannot hold
+	err = h.ImportJSON(strings.NewReader(`{"entries":[{"ip":"10.0.0.1","hostnames":["ok.local"],"block":"my block"}]}`))
+	if !errors.Is(err, ErrInvalidBlock) {
+		t.Errorf("Expected ErrInvalidBlock for a block name with a space, got %v", err)
+	}
+
+	// Test importing comments that would be read back as other lines, a tag or an expiry
+	for _, comment := range []string{`line1\n10.6.6.6 evil.local`, `a\rb`, `ticket=OPS-1 fix`, `until gohosts:expires=2024-01-01T00:00Z`} {
+		err = h.ImportJSON(strings.NewReader(`{"entries":[{"ip":"10.0.0.1","hostnames":["ok.local"],"comment":"` + comment + `"}]}`))
+		if !errors.Is(err, ErrInvalidComment) {
+			t.Errorf("Expected ErrInvalidComment for comment %q, got %v", comment, err)
+		}
+	}
+
+	// Test importing hostnames in their Unicode form, stored in their punycode form as by Add
+	err = h.ImportJSON(strings.NewReader(`{"entries":[{"ip":"10.0.0.1","hostnames":["Bücher.Example."]}]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hostnames := h.Entries()[0].Hostnames; len(hostnames) != 1 || hostnames[0] != "xn--bcher-kva.example" {
+		t.Errorf("expected the hostname to be normalized, got %v", hostnames)
+	}
+}
+
+func TestImportYAML_DefaultActive(t *testing.T) {
+	h := &HostsFile{}
+
+	err := h.ImportYAML(strings.NewReader("entries:\n  - ip: 10.0.0.1\n    hostnames: [api.local]\n  - ip: 10.0.0.2\n    hostnames: [old.local]\n    active: false\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries := h.Entries()
+	if len(entries) != 2 || !entries[0].Active || entries[1].Active {
+		t.Errorf("expected entries without an active field to be active, got %v", entries)
+	}
+}
@@ -0,0 +1,310 @@
+package gohosts
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DefaultColumnSpacing is the number of spaces written between the columns of an entry
+// when the columns are neither aligned nor separated by tabs.
+const DefaultColumnSpacing = 5
+
+// tabWidth is the width assumed for a tab when aligning columns with tabs.
+const tabWidth = 8
+
+// Formatter controls how the entries are written by Save and Format.
+// The zero value writes each entry on a single line with DefaultColumnSpacing spaces
+// between the columns.
+type Formatter struct {
+	// UseTabs separates the columns with tabs instead of spaces.
+	UseTabs bool
+	// Spacing is the number of spaces between the columns, DefaultColumnSpacing if zero.
+	// When columns are aligned, it is the minimum number of spaces between them.
+	Spacing int
+	// AlignColumns aligns the hostnames column of the entries in the same section,
+	// a section being the entries outside of any block or the entries of a block.
+	AlignColumns bool
+	// AlignComments aligns the comment column of the entries in the same section.
+	AlignComments bool
+	// MaxHostnamesPerLine splits the entries with more hostnames over several lines with
+	// the same IP address, 0 means no limit. Windows ignores hostnames past the ninth on a line.
+	// When a limit is set, a line with the same IP address, state and comment as the line before
+	// it is joined back into the same entry when the hosts file is loaded, if that line is full.
+	MaxHostnamesPerLine int
+	// MaxLineLength splits the entries whose line would be longer, in bytes, over several
+	// lines with the same IP address, 0 means no limit. Comment alignment is dropped for the
+	// lines it would push past the limit. A single hostname longer than the limit is written
+	// on its own line. Split lines are joined back on load, as with MaxHostnamesPerLine.
+	MaxLineLength int
+	// LineEnding is the line ending written after each line. If empty, the line ending
+	// of the loaded file is used, "\n" for a new file.
+	LineEnding string
+}
+
+// WithFormatter is a HostsOption that sets the formatter used to write the hosts file.
+func WithFormatter(f Formatter) HostsOption {
+	return func(h *HostsFile) {
+		h.formatter = f
+	}
+}
+
+// Format writes the hosts file content, as it would be saved, to the provided writer.
+// The byte order mark and line ending detected when loading the file are preserved.
+func (h *HostsFile) Format(w io.Writer) error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.format(w)
+}
+
+// format writes the hosts file content to the provided writer, see Format.
+func (h *HostsFile) format(w io.Writer) error {
+	f := h.formatter
+	if f.LineEnding == "" {
+		f.LineEnding = h.encoding.LineEnding
+	}
+
+	if h.encoding.BOM {
+		if _, err := w.Write(utf8BOM); err != nil {
+			return err
+		}
+	}
+
+	// Start with the content that was not parsed as host entries
+	additional := h.additionalContent
+	if f.lineEnding() != "\n" {
+		additional = strings.ReplaceAll(additional, "\n", f.lineEnding())
+	}
+	if _, err := io.WriteString(w, additional); err != nil {
+		return err
+	}
+
+	for _, section := range entrySections(h.entries) {
+		var lines []string
+		if section.block != "" {
+			lines = append(lines, blockBeginLine(section.block))
+		}
+		lines = append(lines, f.formatEntries(section.entries)...)
+		if section.block != "" {
+			lines = append(lines, blockEndLine(section.block))
+		}
+
+		for _, line := range lines {
+			if _, err := io.WriteString(w, line+f.lineEnding()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// row holds the columns of a line written for an entry.
+type row struct {
+	ip        string
+	hostnames string
+	comment   string
+}
+
+// lineEnding returns the line ending written after each line.
+func (f Formatter) lineEnding() string {
+	if f.LineEnding == "" {
+		return "\n"
+	}
+	return f.LineEnding
+}
+
+// spacing returns the number of spaces between the columns.
+func (f Formatter) spacing() int {
+	if f.Spacing <= 0 {
+		return DefaultColumnSpacing
+	}
+	return f.Spacing
+}
+
+// ipColumn returns the first column written for the entry.
+func ipColumn(entry HostEntry) string {
+	if !entry.Active {
+		return "# " + entry.IP
+	}
+	return entry.IP
+}
+
+// rows returns the columns of the lines written for the entry, splitting its hostnames over
+// several lines if needed. Every line gets the comment so the metadata applies to all of them.
+func (f Formatter) rows(entry HostEntry, ipWidth int) []row {
+	ip := ipColumn(entry)
+
+	var comment string
+	if c := entryComment(entry); c != "" {
+		comment = "# " + c
+	}
+
+	// The room left for the hostnames once the other columns are written, without alignment
+	// for the comment column
+	room := 0
+	if f.MaxLineLength > 0 {
+		sep, _ := f.pad(len(ip), ipWidth)
+		room = f.MaxLineLength - len(ip) - len(sep)
+		if comment != "" {
+			sep, _ = f.pad(0, 0)
+			room -= len(sep) + len(comment)
+		}
+	}
+
+	var rows []row
+	for _, hostnames := range f.splitHostnames(entry.Hostnames, room) {
+		rows = append(rows, row{ip: ip, hostnames: strings.Join(hostnames, " "), comment: comment})
+	}
+	return rows
+}
+
+// splitHostnames splits the hostnames in the groups written on the same line, according to
+// MaxHostnamesPerLine and the room left on the line if it is positive. A hostname that does not
+// fit in the room is written on its own line.
+func (f Formatter) splitHostnames(hostnames []string, room int) [][]string {
+	var groups [][]string
+	var group []string
+	length := 0
+
+	for _, hostname := range hostnames {
+		full := f.MaxHostnamesPerLine > 0 && len(group) == f.MaxHostnamesPerLine
+		overflow := f.MaxLineLength > 0 && len(group) > 0 && length+1+len(hostname) > room
+		if full || overflow {
+			groups = append(groups, group)
+			group, length = nil, 0
+		}
+
+		if len(group) > 0 {
+			length++
+		}
+		group = append(group, hostname)
+		length += len(hostname)
+	}
+
+	return append(groups, group)
+}
+
+// wraps reports whether the formatter may split an entry over several lines.
+func (f Formatter) wraps() bool {
+	return f.MaxHostnamesPerLine > 0 || f.MaxLineLength > 0
+}
+
+// full reports whether a line the formatter wrote with the provided hostnames, starting at the
+// provided offset in the line, had no room left for the next hostname of the entry.
+func (f Formatter) full(offset int, hostnames []string, comment, next string) bool {
+	if f.MaxHostnamesPerLine > 0 && len(hostnames) >= f.MaxHostnamesPerLine {
+		return true
+	}
+	if f.MaxLineLength <= 0 {
+		return false
+	}
+
+	// The room is computed as rows does, for a line holding the provided hostnames
+	room := f.MaxLineLength - offset
+	if comment != "" {
+		sep, _ := f.pad(0, 0)
+		room -= len(sep) + len("# "+comment)
+	}
+	return len(strings.Join(hostnames, " "))+1+len(next) > room
+}
+
+// continues reports whether the entry parsed from the line right after the previous entry
+// continues it, as written by a formatter splitting the hostnames of an entry over several lines.
+// The previous line must also be full, see full.
+func continues(previous, entry HostEntry) bool {
+	return previous.IP == entry.IP &&
+		previous.Active == entry.Active &&
+		previous.Block == entry.Block &&
+		previous.Comment == entry.Comment &&
+		equalTags(previous.Tags, entry.Tags) &&
+		previous.Expires.Equal(entry.Expires)
+}
+
+// formatEntries returns the lines written for the entries of a section.
+func (f Formatter) formatEntries(entries []HostEntry) []string {
+	// The IP column does not depend on how the hostnames are split, so its width comes first
+	ipWidth := 0
+	if f.AlignColumns {
+		for _, entry := range entries {
+			ipWidth = max(ipWidth, len(ipColumn(entry)))
+		}
+	}
+
+	var rows []row
+	for _, entry := range entries {
+		rows = append(rows, f.rows(entry, ipWidth)...)
+	}
+
+	hostnamesWidth := 0
+	if f.AlignComments {
+		for _, r := range rows {
+			_, start := f.pad(len(r.ip), ipWidth)
+			hostnamesWidth = max(hostnamesWidth, start+len(r.hostnames))
+		}
+	}
+
+	lines := make([]string, len(rows))
+	for i, r := range rows {
+		sep, start := f.pad(len(r.ip), ipWidth)
+		line := r.ip + sep + r.hostnames
+		if r.comment != "" {
+			sep, _ = f.pad(start+len(r.hostnames), hostnamesWidth)
+			if f.MaxLineLength > 0 && len(line)+len(sep)+len(r.comment) > f.MaxLineLength {
+				// Drop the comment alignment rather than going past the line length limit
+				sep, _ = f.pad(start+len(r.hostnames), 0)
+			}
+			line += sep + r.comment
+		}
+		lines[i] = line
+	}
+	return lines
+}
+
+// pad returns the separator written after a column ending at the provided position, along with
+// the position where the next column starts. When aligning, width is the position of the end of
+// the widest column, so every next column starts at the same position.
+func (f Formatter) pad(position, width int) (string, int) {
+	if f.UseTabs {
+		// Each tab moves to the next tab stop, stop at the first one after the widest column
+		tabs := 1
+		next := (position/tabWidth + 1) * tabWidth
+		for ; next <= width; next += tabWidth {
+			tabs++
+		}
+		return strings.Repeat("\t", tabs), next
+	}
+
+	spaces := f.spacing() + max(width-position, 0)
+	return strings.Repeat(" ", spaces), position + spaces
+}
+
+// formatEntry returns the line representation of a host entry using the default formatter.
+func formatEntry(entry HostEntry) string {
+	return Formatter{}.formatEntries([]HostEntry{entry})[0]
+}
+
+// entryComment returns the comment written for a host entry, including its structured metadata.
+func entryComment(entry HostEntry) string {
+	comment := strings.TrimSpace(formatTags(entry.Tags) + " " + entry.Comment)
+	if !entry.Expires.IsZero() {
+		comment = strings.TrimSpace(comment + " " + ExpiresKey + "=" + formatExpires(entry.Expires))
+	}
+	return comment
+}
+
+// validateComment checks that the comment of an entry can be written on its line and parsed back,
+// without line breaks, a leading word that would be read back as a tag, nor an expiry.
+func validateComment(comment string) error {
+	if strings.ContainsAny(comment, "\r\n") {
+		return fmt.Errorf("%w: %q", ErrInvalidComment, comment)
+	}
+	for i, field := range strings.Fields(comment) {
+		_, _, tag := parseTag(field)
+		if (i == 0 && tag) || strings.HasPrefix(field, ExpiresKey+"=") {
+			return fmt.Errorf("%w: %q", ErrInvalidComment, comment)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package gohosts
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+const testFormatHosts = `# header
+127.0.0.1 localhost # loopback
+# 10.0.0.1 a.local b.local c.local
+::1 localhost # ipv6
+# gohosts:begin dev
+10.0.0.2 api.dev.local # dev
+# gohosts:end dev
+`
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name      string
+		formatter Formatter
+		expected  string
+	}{
+		{
+			name:      "default",
+			formatter: Formatter{},
+			expected: "# header\n" +
+				"127.0.0.1     localhost     # loopback\n" +
+				"# 10.0.0.1     a.local b.local c.local\n" +
+				"::1     localhost     # ipv6\n" +
+				"# gohosts:begin dev\n" +
+				"10.0.0.2     api.dev.local     # dev\n" +
+				"# gohosts:end dev\n",
+		},
+		{
+			name:      "aligned spaces",
+			formatter: Formatter{Spacing: 1, AlignColumns: true, AlignComments: true},
+			expected: "# header\n" +
+				"127.0.0.1  localhost               # loopback\n" +
+				"# 10.0.0.1 a.local b.local c.local\n" +
+				"::1        localhost               # ipv6\n" +
+				"# gohosts:begin dev\n" +
+				"10.0.0.2 api.dev.local # dev\n" +
+				"# gohosts:end dev\n",
+		},
+		{
+			name:      "aligned tabs",
+			formatter: Formatter{UseTabs: true, AlignColumns: true, AlignComments: true},
+			expected: "# header\n" +
+				"127.0.0.1\tlocalhost\t\t# loopback\n" +
+				"# 10.0.0.1\ta.local b.local c.local\n" +
+				"::1\t\tlocalhost\t\t# ipv6\n" +
+				"# gohosts:begin dev\n" +
+				"10.0.0.2\tapi.dev.local\t# dev\n" +
+				"# gohosts:end dev\n",
+		},
+		{
+			name:      "max line length",
+			formatter: Formatter{Spacing: 1, AlignComments: true, MaxLineLength: 30},
+			expected: "# header\n" +
+				"127.0.0.1 localhost # loopback\n" +
+				"# 10.0.0.1 a.local b.local\n" +
+				"# 10.0.0.1 c.local\n" +
+				"::1 localhost # ipv6\n" +
+				"# gohosts:begin dev\n" +
+				"10.0.0.2 api.dev.local # dev\n" +
+				"# gohosts:end dev\n",
+		},
+		{
+			name:      "max hostnames and line ending",
+			formatter: Formatter{Spacing: 1, MaxHostnamesPerLine: 2, LineEnding: "\r\n"},
+			expected: "# header\r\n" +
+				"127.0.0.1 localhost # loopback\r\n" +
+				"# 10.0.0.1 a.local b.local\r\n" +
+				"# 10.0.0.1 c.local\r\n" +
+				"::1 localhost # ipv6\r\n" +
+				"# gohosts:begin dev\r\n" +
+				"10.0.0.2 api.dev.local # dev\r\n" +
+				"# gohosts:end dev\r\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, _ := newTestHostsFile(t, testFormatHosts, WithFormatter(test.formatter))
+
+			var buf bytes.Buffer
+			if err := h.Format(&buf); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if buf.String() != test.expected {
+				t.Errorf("\n%q\n\n%q", test.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestSave_WithFormatter(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "hosts_test")
+	if err != nil {
+		t.Fatalf("Error creating temporary file: %v", err)
+	}
+	defer tempFile.Close()
+	defer os.Remove(tempFile.Name())
+
+	h, err := New(WithPath(tempFile.Name()), WithFormatter(Formatter{UseTabs: true}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = h.Add("127.0.0.1", []string{"localhost"}, "")
+	if err != nil {
+		t.Fatalf("failed to add entry: %v", err)
+	}
+	err = h.Save()
+	if err != nil {
+		t.Fatalf("failed to save hosts file: %v", err)
+	}
+
+	content, err := os.ReadFile(tempFile.Name())
+	if err != nil {
+		t.Fatalf("failed to read hosts file: %v", err)
+	}
+	if string(content) != "127.0.0.1\tlocalhost\n" {
+		t.Errorf("unexpected content in hosts file: %q", content)
+	}
+}
+
+func TestParseHosts_WrappedLines(t *testing.T) {
+	h := &HostsFile{formatter: Formatter{MaxHostnamesPerLine: 2}}
+
+	entries, err := h.parseHosts([]string{
+		"10.0.0.1 a.local",
+		"10.0.0.1 b.local",
+		"10.0.0.2 c.local d.local",
+		"10.0.0.2 e.local",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Only a line continuing a full line is joined back, lines written separately are kept
+	expected := []HostEntry{
+		{Line: 1, IP: "10.0.0.1", Hostnames: []string{"a.local"}, Active: true},
+		{Line: 2, IP: "10.0.0.1", Hostnames: []string{"b.local"}, Active: true},
+		{Line: 3, IP: "10.0.0.2", Hostnames: []string{"c.local", "d.local", "e.local"}, Active: true},
+	}
+	if !Equal(entries, expected) {
+		t.Errorf("Entries do not match expected entries. \nGot: %v, \nExpected: %v", entries, expected)
+	}
+	for i := range expected {
+		if entries[i].Line != expected[i].Line {
+			t.Errorf("expected entry %d on line %d, got %d", i, expected[i].Line, entries[i].Line)
+		}
+	}
+}
+
+func TestFormat_WrappedRoundTrip(t *testing.T) {
+	formatters := []Formatter{
+		{MaxLineLength: 40},
+		{Spacing: 1, AlignColumns: true, AlignComments: true, MaxLineLength: 40},
+		{UseTabs: true, AlignColumns: true, MaxLineLength: 40},
+	}
+	for _, formatter := range formatters {
+		h, _ := newTestHostsFile(t, "", WithFormatter(formatter))
+		if err := h.Add("10.0.0.1", []string{"a.local", "b.local", "c.local", "d.local", "e.local"}, "wrapped", WithTags(map[string]string{"owner": "web"})); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := h.Add("10.10.10.10", []string{"f.local"}, ""); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var buf bytes.Buffer
+		if err := h.Format(&buf); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		entries, err := h.parseHosts(strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !Equal(entries, h.Entries()) {
+			t.Errorf("expected the entries to be read back with %+v, got %v from:\n%s", formatter, entries, buf.String())
+		}
+	}
+}
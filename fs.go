@@ -0,0 +1,372 @@
+package gohosts
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FS is the filesystem the hosts file, its backups and the profiles are read from and written to.
+// Names are paths in the format of the operating system, such as "/etc/hosts".
+type FS interface {
+	// Open opens the named file for reading.
+	Open(name string) (fs.File, error)
+	// Create creates the named file for writing, truncating it if it already exists.
+	Create(name string) (io.WriteCloser, error)
+	// Append opens the named file for appending, creating it if it does not exist.
+	Append(name string) (io.WriteCloser, error)
+	// Stat returns the file info of the named file.
+	Stat(name string) (fs.FileInfo, error)
+	// ReadDir returns the entries of the named directory, sorted by filename.
+	ReadDir(name string) ([]fs.DirEntry, error)
+	// Rename renames the file oldname to newname, replacing newname if it already exists.
+	Rename(oldname, newname string) error
+	// MkdirAll creates the named directory, along with any missing parent directory.
+	MkdirAll(name string, perm fs.FileMode) error
+	// Remove removes the named file or empty directory.
+	Remove(name string) error
+}
+
+// WithFS is a HostsOption that sets the filesystem used to read and write the hosts file,
+// its backups and the profiles. The operating system filesystem is used by default.
+func WithFS(fsys FS) HostsOption {
+	return func(h *HostsFile) {
+		h.fsys = fsys
+	}
+}
+
+// filesystem returns the filesystem of the hosts file.
+func (h *HostsFile) filesystem() FS {
+	if h.fsys == nil {
+		return OSFS{}
+	}
+	return h.fsys
+}
+
+// readFile reads the whole named file from the filesystem.
+func readFile(fsys FS, name string) ([]byte, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
+
+// writeFile writes the data to the named file, creating it if needed.
+func writeFile(fsys FS, name string, data []byte) error {
+	file, err := fsys.Create(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// writeFileAtomic writes the data to a temporary file renamed to the named file, so readers
+// never see a partially written file.
+func writeFileAtomic(fsys FS, name string, data []byte) error {
+	tmp := name + ".tmp"
+	if err := writeFile(fsys, tmp, data); err != nil {
+		fsys.Remove(tmp)
+		return err
+	}
+	return fsys.Rename(tmp, name)
+}
+
+// OSFS is the FS of the operating system, backed by the os package.
+type OSFS struct{}
+
+// Open implements the FS interface.
+func (OSFS) Open(name string) (fs.File, error) {
+	return os.Open(name)
+}
+
+// Create implements the FS interface. New files are created with 0644 permissions.
+func (OSFS) Create(name string) (io.WriteCloser, error) {
+	return os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+}
+
+// Append implements the FS interface. New files are created with 0644 permissions.
+func (OSFS) Append(name string) (io.WriteCloser, error) {
+	return os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+}
+
+// Stat implements the FS interface.
+func (OSFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+// ReadDir implements the FS interface.
+func (OSFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+// Rename implements the FS interface.
+func (OSFS) Rename(oldname, newname string) error {
+	return os.Rename(oldname, newname)
+}
+
+// MkdirAll implements the FS interface.
+func (OSFS) MkdirAll(name string, perm fs.FileMode) error {
+	return os.MkdirAll(name, perm)
+}
+
+// Remove implements the FS interface.
+func (OSFS) Remove(name string) error {
+	return os.Remove(name)
+}
+
+// MemFS is an in-memory FS, safe for concurrent use. The zero value is not usable,
+// use NewMemFS to create one.
+type MemFS struct {
+	mu    sync.Mutex
+	files map[string]*memFile
+}
+
+// memFile is a file or a directory of a MemFS.
+type memFile struct {
+	data    []byte
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+// NewMemFS creates an empty in-memory filesystem, holding only its root directory.
+func NewMemFS() *MemFS {
+	root := filepath.Clean(string(filepath.Separator))
+	return &MemFS{
+		files: map[string]*memFile{
+			root: {mode: fs.ModeDir | 0755, modTime: time.Now()},
+			".":  {mode: fs.ModeDir | 0755, modTime: time.Now()},
+		},
+	}
+}
+
+// WriteFile writes the data to the named file, creating it and its parent directories if needed.
+func (m *MemFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	if err := m.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name = filepath.Clean(name)
+	if file, ok := m.files[name]; ok && file.mode.IsDir() {
+		return &fs.PathError{Op: "write", Path: name, Err: fs.ErrInvalid}
+	}
+	m.files[name] = &memFile{data: bytes.Clone(data), mode: perm, modTime: time.Now()}
+	return nil
+}
+
+// ReadFile returns the content of the named file.
+func (m *MemFS) ReadFile(name string) ([]byte, error) {
+	return readFile(m, name)
+}
+
+// Open implements the FS interface.
+func (m *MemFS) Open(name string) (fs.File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name = filepath.Clean(name)
+	file, ok := m.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &memReader{Reader: bytes.NewReader(bytes.Clone(file.data)), info: file.info(name)}, nil
+}
+
+// Create implements the FS interface.
+func (m *MemFS) Create(name string) (io.WriteCloser, error) {
+	return m.open(name, true)
+}
+
+// Append implements the FS interface.
+func (m *MemFS) Append(name string) (io.WriteCloser, error) {
+	return m.open(name, false)
+}
+
+// open opens the named file for writing, creating it if needed and truncating it if truncate is true.
+func (m *MemFS) open(name string, truncate bool) (io.WriteCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name = filepath.Clean(name)
+	if parent, ok := m.files[filepath.Dir(name)]; !ok || !parent.mode.IsDir() {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	file, ok := m.files[name]
+	switch {
+	case !ok:
+		file = &memFile{mode: 0644}
+		m.files[name] = file
+	case file.mode.IsDir():
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if truncate {
+		file.data = nil
+	}
+	file.modTime = time.Now()
+
+	return &memWriter{fs: m, file: file}, nil
+}
+
+// Stat implements the FS interface.
+func (m *MemFS) Stat(name string) (fs.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name = filepath.Clean(name)
+	file, ok := m.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return file.info(name), nil
+}
+
+// ReadDir implements the FS interface.
+func (m *MemFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name = filepath.Clean(name)
+	if dir, ok := m.files[name]; !ok || !dir.mode.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	var entries []fs.DirEntry
+	for path, file := range m.files {
+		if path != name && filepath.Dir(path) == name {
+			entries = append(entries, fs.FileInfoToDirEntry(file.info(path)))
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
+
+// Rename implements the FS interface. Only files can be renamed.
+func (m *MemFS) Rename(oldname, newname string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	oldname, newname = filepath.Clean(oldname), filepath.Clean(newname)
+	file, ok := m.files[oldname]
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: fs.ErrNotExist}
+	}
+	if parent, ok := m.files[filepath.Dir(newname)]; !ok || !parent.mode.IsDir() {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: fs.ErrNotExist}
+	}
+	if existing, ok := m.files[newname]; file.mode.IsDir() || (ok && existing.mode.IsDir()) {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: fs.ErrInvalid}
+	}
+
+	delete(m.files, oldname)
+	m.files[newname] = file
+	return nil
+}
+
+// MkdirAll implements the FS interface.
+func (m *MemFS) MkdirAll(name string, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name = filepath.Clean(name)
+	for dir := name; ; dir = filepath.Dir(dir) {
+		if file, ok := m.files[dir]; ok {
+			if !file.mode.IsDir() {
+				return &fs.PathError{Op: "mkdir", Path: dir, Err: fs.ErrExist}
+			}
+			break
+		}
+		m.files[dir] = &memFile{mode: fs.ModeDir | perm, modTime: time.Now()}
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+	return nil
+}
+
+// Remove implements the FS interface.
+func (m *MemFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name = filepath.Clean(name)
+	file, ok := m.files[name]
+	if !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	if file.mode.IsDir() {
+		prefix := strings.TrimSuffix(name, string(filepath.Separator)) + string(filepath.Separator)
+		for path := range m.files {
+			if strings.HasPrefix(path, prefix) {
+				return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrExist}
+			}
+		}
+	}
+
+	delete(m.files, name)
+	return nil
+}
+
+// info returns the file info of the file with the provided path.
+func (f *memFile) info(path string) fs.FileInfo {
+	return memFileInfo{name: filepath.Base(path), size: int64(len(f.data)), mode: f.mode, modTime: f.modTime}
+}
+
+// memFileInfo is the fs.FileInfo of a MemFS file.
+type memFileInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func (i memFileInfo) Name() string       { return i.name }
+func (i memFileInfo) Size() int64        { return i.size }
+func (i memFileInfo) Mode() fs.FileMode  { return i.mode }
+func (i memFileInfo) ModTime() time.Time { return i.modTime }
+func (i memFileInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i memFileInfo) Sys() any           { return nil }
+
+// memReader is a MemFS file opened for reading, holding a copy of the file content.
+type memReader struct {
+	*bytes.Reader
+	info fs.FileInfo
+}
+
+func (r *memReader) Stat() (fs.FileInfo, error) { return r.info, nil }
+func (r *memReader) Close() error               { return nil }
+
+// memWriter is a MemFS file opened for writing, written through to the file.
+type memWriter struct {
+	fs   *MemFS
+	file *memFile
+}
+
+func (w *memWriter) Write(p []byte) (int, error) {
+	w.fs.mu.Lock()
+	defer w.fs.mu.Unlock()
+
+	w.file.data = append(w.file.data, p...)
+	w.file.modTime = time.Now()
+	return len(p), nil
+}
+
+func (w *memWriter) Close() error { return nil }
@@ -0,0 +1,135 @@
+package gohosts
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func TestMemFS(t *testing.T) {
+	m := NewMemFS()
+
+	err := m.WriteFile("/etc/hosts", []byte("127.0.0.1 localhost\n"), 0644)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := m.ReadFile("/etc/hosts")
+	if err != nil || string(data) != "127.0.0.1 localhost\n" {
+		t.Errorf("unexpected content: %q, %v", data, err)
+	}
+
+	info, err := m.Stat("/etc")
+	if err != nil || !info.IsDir() {
+		t.Errorf("expected /etc to be a directory, got %v, %v", info, err)
+	}
+
+	// Files cannot be created in missing directories
+	_, err = m.Create("/missing/hosts")
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+
+	w, err := m.Create("/etc/hosts.new")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	w.Write([]byte("::1 localhost\n"))
+	w.Close()
+
+	w, err = m.Append("/etc/hosts.new")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	w.Write([]byte("::2 other\n"))
+	w.Close()
+	data, _ = m.ReadFile("/etc/hosts.new")
+	if string(data) != "::1 localhost\n::2 other\n" {
+		t.Errorf("unexpected content after append: %q", data)
+	}
+
+	err = m.Rename("/etc/hosts.new", "/etc/hosts")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ = m.ReadFile("/etc/hosts")
+	if string(data) != "::1 localhost\n::2 other\n" {
+		t.Errorf("unexpected content after rename: %q", data)
+	}
+
+	entries, err := m.ReadDir("/etc")
+	if err != nil || len(entries) != 1 || entries[0].Name() != "hosts" {
+		t.Errorf("unexpected directory entries: %v, %v", entries, err)
+	}
+
+	if err := m.Remove("/etc"); err == nil {
+		t.Errorf("Expected an error for removing a directory that is not empty")
+	}
+	if err := m.Remove("/etc/hosts"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := m.Open("/etc/hosts"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+}
+
+func TestWithFS(t *testing.T) {
+	m := NewMemFS()
+	err := m.WriteFile("/etc/hosts", []byte("# header\n127.0.0.1 localhost\n"), 0644)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The path must exist in the provided filesystem, not on disk
+	_, err = New(WithFS(m), WithPath("/etc/missing"))
+	if err == nil {
+		t.Errorf("Expected an error for a missing hosts file")
+	}
+
+	h, err := New(WithFS(m), WithPath("/etc/hosts"), WithProfileDir("/profiles"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := h.Load(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := h.Add("10.0.0.1", []string{"api.local"}, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := h.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, _ := m.ReadFile("/etc/hosts")
+	if !strings.Contains(string(data), "10.0.0.1     api.local") {
+		t.Errorf("unexpected content: %q", data)
+	}
+
+	backups, err := getBackupFiles(m, "/etc/hosts")
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("expected 1 backup, got %v, %v", backups, err)
+	}
+
+	if err := h.RestoreBackup(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ = m.ReadFile("/etc/hosts")
+	if string(data) != "# header\n127.0.0.1 localhost\n" {
+		t.Errorf("unexpected content after restore: %q", data)
+	}
+
+	// Profiles are stored in the same filesystem
+	err = h.SaveProfile("local", HostEntry{IP: "10.0.0.2", Hostnames: []string{"web.local"}, Active: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := m.Stat("/profiles/local.json"); err != nil {
+		t.Errorf("expected the profile to be written to the filesystem: %v", err)
+	}
+	profiles, err := h.ListProfiles()
+	if err != nil || len(profiles) != 1 || profiles[0] != "local" {
+		t.Errorf("unexpected profiles: %v, %v", profiles, err)
+	}
+}
@@ -1,3 +1,10 @@
 module github.com/aymansor/gohosts
 
-go 1.21.6
+go 1.24
+
+require (
+	golang.org/x/net v0.24.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require golang.org/x/text v0.14.0 // indirect
@@ -0,0 +1,41 @@
+package gohoststest
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultTime is the time the clock of a fixture starts at.
+var DefaultTime = time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+// FakeClock is a gohosts.Clock whose time only changes when set or advanced, safe for concurrent use.
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFakeClock creates a fake clock starting at the provided time.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the current time of the clock.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Set sets the current time of the clock.
+func (c *FakeClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// Advance moves the clock forward by the provided duration.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
@@ -0,0 +1,210 @@
+// Package gohoststest provides fixtures and assertions to test code using gohosts.
+//
+// Fixtures are isolated hosts files created from inline text and backed by an in-memory
+// filesystem, with a fake clock for the backup timestamps and the expiry of entries:
+//
+//	f := gohoststest.NewFixture(t, `
+//		127.0.0.1 localhost
+//		10.0.0.1  api.local
+//	`)
+//	f.Add("10.0.0.2", []string{"web.local"}, "")
+//	gohoststest.AssertResolves(t, f.HostsFile, "web.local", "10.0.0.2")
+package gohoststest
+
+import (
+	"fmt"
+	"net/netip"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aymansor/gohosts"
+)
+
+// FixturePath is the path of the hosts file of a fixture, in its in-memory filesystem.
+const FixturePath = "/etc/hosts"
+
+// Fixture is an isolated hosts file created from inline text. The hosts file is loaded and
+// its methods can be called on the fixture directly.
+type Fixture struct {
+	*gohosts.HostsFile
+	// FS is the in-memory filesystem holding the hosts file, its backups and profiles.
+	FS *gohosts.MemFS
+	// Clock is the clock of the hosts file, used for the backup timestamps and expiry.
+	Clock *FakeClock
+}
+
+// NewFixture creates and loads a hosts file with the provided content, at FixturePath in an
+// in-memory filesystem. The common indentation of the content is removed, so it can be written
+// as an indented raw string literal. The options are applied after the ones of the fixture.
+func NewFixture(t testing.TB, content string, opts ...gohosts.HostsOption) *Fixture {
+	t.Helper()
+
+	fsys := gohosts.NewMemFS()
+	if err := fsys.WriteFile(FixturePath, []byte(Dedent(content)), 0644); err != nil {
+		t.Fatalf("failed to write hosts file: %v", err)
+	}
+
+	clock := NewFakeClock(DefaultTime)
+	opts = append([]gohosts.HostsOption{
+		gohosts.WithFS(fsys),
+		gohosts.WithPath(FixturePath),
+		gohosts.WithClock(clock),
+		gohosts.WithProfileDir("/profiles"),
+	}, opts...)
+
+	h, err := gohosts.New(opts...)
+	if err != nil {
+		t.Fatalf("failed to create hosts file: %v", err)
+	}
+	if err := h.Load(); err != nil {
+		t.Fatalf("failed to load hosts file: %v", err)
+	}
+
+	return &Fixture{HostsFile: h, FS: fsys, Clock: clock}
+}
+
+// Content returns the content of the hosts file in the filesystem, as last written by Save.
+func (f *Fixture) Content(t testing.TB) string {
+	t.Helper()
+
+	data, err := f.FS.ReadFile(FixturePath)
+	if err != nil {
+		t.Fatalf("failed to read hosts file: %v", err)
+	}
+	return string(data)
+}
+
+// Backups returns the names of the backup files of the hosts file, oldest first.
+func (f *Fixture) Backups(t testing.TB) []string {
+	t.Helper()
+
+	entries, err := f.FS.ReadDir(filepath.Dir(FixturePath))
+	if err != nil {
+		t.Fatalf("failed to list backups: %v", err)
+	}
+
+	var backups []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), filepath.Base(FixturePath)+"_"+gohosts.BackupFileInfix+"_") {
+			backups = append(backups, entry.Name())
+		}
+	}
+	return backups
+}
+
+// Dedent removes the common indentation of the lines of the text, along with its leading blank
+// line and the indentation of its last line if it is blank.
+func Dedent(text string) string {
+	lines := strings.Split(text, "\n")
+	if len(lines) > 1 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	if last := len(lines) - 1; strings.TrimSpace(lines[last]) == "" {
+		lines[last] = ""
+	}
+
+	indent, first := "", true
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		prefix := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if first {
+			indent, first = prefix, false
+		}
+		for !strings.HasPrefix(prefix, indent) {
+			indent = indent[:len(indent)-1]
+		}
+	}
+
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(line, indent)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// AssertResolves checks that the hostname resolves to the IP address, that is the first active
+// entry mapping the hostname in the address family of the IP address maps it to that address.
+func AssertResolves(t testing.TB, h *gohosts.HostsFile, hostname, ip string) {
+	t.Helper()
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		t.Fatalf("invalid IP address: %s", ip)
+		return
+	}
+
+	resolved, ok := resolve(h, hostname, addr.Unmap().Is4())
+	switch {
+	case !ok:
+		t.Errorf("%s does not resolve, expected %s", hostname, ip)
+	case !gohosts.IPIs(ip)(resolved):
+		t.Errorf("%s resolves to %s on line %d, expected %s", hostname, resolved.IP, resolved.Line, ip)
+	}
+}
+
+// AssertNotResolves checks that no active entry maps the hostname, in any address family.
+func AssertNotResolves(t testing.TB, h *gohosts.HostsFile, hostname string) {
+	t.Helper()
+
+	for _, ipv4 := range []bool{true, false} {
+		if resolved, ok := resolve(h, hostname, ipv4); ok {
+			t.Errorf("%s resolves to %s on line %d, expected no resolution", hostname, resolved.IP, resolved.Line)
+		}
+	}
+}
+
+// resolve returns the first active entry mapping the hostname in the address family.
+func resolve(h *gohosts.HostsFile, hostname string, ipv4 bool) (gohosts.HostEntry, bool) {
+	for _, entry := range h.Filter(gohosts.And(gohosts.Hostname(hostname), gohosts.IsActive(true))) {
+		if addr := entry.Addr(); addr.IsValid() && addr.Is4() == ipv4 {
+			return entry, true
+		}
+	}
+	return gohosts.HostEntry{}, false
+}
+
+// AssertEntries checks that the entries of the hosts file are the expected ones, in order.
+// The line numbers of the entries are not compared.
+func AssertEntries(t testing.TB, h *gohosts.HostsFile, expected ...gohosts.HostEntry) {
+	t.Helper()
+
+	entries := h.Entries()
+	if gohosts.Equal(entries, expected) {
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "entries do not match the expected entries\ngot:\n")
+	for _, entry := range entries {
+		fmt.Fprintf(&b, "\t%s\n", describe(entry))
+	}
+	fmt.Fprintf(&b, "expected:\n")
+	for _, entry := range expected {
+		fmt.Fprintf(&b, "\t%s\n", describe(entry))
+	}
+	t.Error(b.String())
+}
+
+// describe returns a single line description of the entry.
+func describe(entry gohosts.HostEntry) string {
+	description := fmt.Sprintf("%s %s", entry.IP, strings.Join(entry.Hostnames, " "))
+	if !entry.Active {
+		description = "# " + description
+	}
+	if entry.Comment != "" {
+		description += " comment=" + fmt.Sprintf("%q", entry.Comment)
+	}
+	if len(entry.Tags) > 0 {
+		description += fmt.Sprintf(" tags=%v", entry.Tags)
+	}
+	if !entry.Expires.IsZero() {
+		description += " expires=" + entry.Expires.Format(time.RFC3339)
+	}
+	if entry.Block != "" {
+		description += " block=" + entry.Block
+	}
+	return description
+}
@@ -0,0 +1,151 @@
+package gohoststest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aymansor/gohosts"
+)
+
+// recorder is a testing.TB recording the failures instead of failing the test.
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Error(args ...any) {
+	r.failures = append(r.failures, fmt.Sprint(args...))
+}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+const fixtureHosts = `
+	# header
+	127.0.0.1 localhost
+	::1       localhost
+	10.0.0.1  api.local
+	10.0.0.2  api.local web.local
+	# 10.0.0.3 db.local
+`
+
+func TestDedent(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"127.0.0.1 localhost\n", "127.0.0.1 localhost\n"},
+		{"\n\t\t127.0.0.1 localhost\n\t\t  ::1 localhost\n\t", "127.0.0.1 localhost\n  ::1 localhost\n"},
+		{"\n    a\n\n  b\n", "  a\n\nb\n"},
+	}
+
+	for _, test := range tests {
+		if got := Dedent(test.text); got != test.expected {
+			t.Errorf("Dedent(%q) = %q, expected %q", test.text, got, test.expected)
+		}
+	}
+}
+
+func TestFixture(t *testing.T) {
+	f := NewFixture(t, fixtureHosts)
+
+	AssertEntries(t, f.HostsFile,
+		gohosts.HostEntry{IP: "127.0.0.1", Hostnames: []string{"localhost"}, Active: true},
+		gohosts.HostEntry{IP: "::1", Hostnames: []string{"localhost"}, Active: true},
+		gohosts.HostEntry{IP: "10.0.0.1", Hostnames: []string{"api.local"}, Active: true},
+		gohosts.HostEntry{IP: "10.0.0.2", Hostnames: []string{"api.local", "web.local"}, Active: true},
+		gohosts.HostEntry{IP: "10.0.0.3", Hostnames: []string{"db.local"}, Active: false},
+	)
+
+	AssertResolves(t, f.HostsFile, "localhost", "127.0.0.1")
+	AssertResolves(t, f.HostsFile, "localhost", "::1")
+	AssertResolves(t, f.HostsFile, "API.local", "10.0.0.1")
+	AssertResolves(t, f.HostsFile, "web.local", "10.0.0.2")
+	AssertNotResolves(t, f.HostsFile, "db.local")
+
+	if err := f.Add("10.0.0.4", []string{"cache.local"}, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := f.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	AssertGolden(t, f.HostsFile, "testdata/fixture.hosts")
+
+	expected := "# header\n127.0.0.1     localhost\n::1     localhost\n10.0.0.1     api.local\n" +
+		"10.0.0.2     api.local web.local\n# 10.0.0.3     db.local\n10.0.0.4     cache.local\n"
+	if content := f.Content(t); content != expected {
+		t.Errorf("unexpected content: %q", content)
+	}
+}
+
+func TestAssertions_Failures(t *testing.T) {
+	f := NewFixture(t, fixtureHosts)
+
+	golden := filepath.Join(t.TempDir(), "golden.hosts")
+	if err := os.WriteFile(golden, []byte("127.0.0.1 localhost\n"), 0644); err != nil {
+		t.Fatalf("failed to write golden file: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		assert func(t testing.TB)
+	}{
+		{"shadowed mapping", func(t testing.TB) { AssertResolves(t, f.HostsFile, "api.local", "10.0.0.2") }},
+		{"disabled entry", func(t testing.TB) { AssertResolves(t, f.HostsFile, "db.local", "10.0.0.3") }},
+		{"wrong family", func(t testing.TB) { AssertResolves(t, f.HostsFile, "api.local", "::2") }},
+		{"invalid IP", func(t testing.TB) { AssertResolves(t, f.HostsFile, "api.local", "invalid") }},
+		{"resolved", func(t testing.TB) { AssertNotResolves(t, f.HostsFile, "web.local") }},
+		{"entries", func(t testing.TB) {
+			AssertEntries(t, f.HostsFile, gohosts.HostEntry{IP: "127.0.0.1", Hostnames: []string{"localhost"}, Active: true})
+		}},
+		{"golden", func(t testing.TB) { AssertGolden(t, f.HostsFile, golden) }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.name == "golden" && *update {
+				t.Skip("golden files are being updated")
+			}
+			r := &recorder{TB: t}
+			test.assert(r)
+			if len(r.failures) == 0 {
+				t.Errorf("expected the assertion to fail")
+			}
+		})
+	}
+}
+
+func TestFakeClock(t *testing.T) {
+	f := NewFixture(t, `
+		127.0.0.1 localhost
+		10.0.0.1  debug.local # gohosts:expires=2024-01-01T13:00Z
+	`, gohosts.WithAutoPrune())
+
+	// The fixture clock starts at DefaultTime, before the expiry
+	AssertResolves(t, f.HostsFile, "debug.local", "10.0.0.1")
+
+	if err := f.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f.Clock.Advance(2 * time.Hour)
+	if err := f.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	AssertNotResolves(t, f.HostsFile, "debug.local")
+
+	backups := f.Backups(t)
+	expected := []string{"hosts_gohosts_20240101120000.bak", "hosts_gohosts_20240101140000.bak"}
+	if len(backups) != len(expected) || backups[0] != expected[0] || backups[1] != expected[1] {
+		t.Errorf("expected backups %v, got %v", expected, backups)
+	}
+}
@@ -0,0 +1,46 @@
+package gohoststest
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aymansor/gohosts"
+)
+
+// update rewrites the golden files with the actual content instead of comparing them.
+var update = flag.Bool("gohoststest.update", false, "update the golden files of gohoststest.AssertGolden")
+
+// AssertGolden checks that the content Save would write for the hosts file matches the golden file.
+// Run the tests with -gohoststest.update to create or update the golden files.
+func AssertGolden(t testing.TB, h *gohosts.HostsFile, golden string) {
+	t.Helper()
+
+	var b bytes.Buffer
+	if _, err := h.WriteTo(&b); err != nil {
+		t.Fatalf("failed to write hosts file: %v", err)
+		return
+	}
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(golden), 0755); err != nil {
+			t.Fatalf("failed to create golden file directory: %v", err)
+			return
+		}
+		if err := os.WriteFile(golden, b.Bytes(), 0644); err != nil {
+			t.Fatalf("failed to update golden file: %v", err)
+		}
+		return
+	}
+
+	expected, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("failed to read golden file, run with -gohoststest.update to create it: %v", err)
+		return
+	}
+	if !bytes.Equal(b.Bytes(), expected) {
+		t.Errorf("hosts file does not match golden file %s\ngot:\n%s\nexpected:\n%s", golden, b.Bytes(), expected)
+	}
+}
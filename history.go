@@ -0,0 +1,148 @@
+package gohosts
+
+import (
+	"errors"
+)
+
+// DefaultHistoryDepth is the number of edits kept in the history unless WithHistoryDepth is set.
+const DefaultHistoryDepth = 100
+
+var (
+	// ErrNothingToUndo is returned by Undo when the history has no edit to undo.
+	ErrNothingToUndo = errors.New("nothing to undo")
+	// ErrNothingToRedo is returned by Redo when no edit was undone since the last edit.
+	ErrNothingToRedo = errors.New("nothing to redo")
+)
+
+// Edit is a change of the content of the hosts file made by a mutating operation, recorded in the
+// history so it can be undone and redone.
+type Edit struct {
+	// Operation is the name of the method that made the edit, such as "Add" or "DisableWhere".
+	Operation string
+
+	// The content before and after the edit. Entries are never changed in place, so the edit
+	// shares them with the hosts file.
+	before, after               []HostEntry
+	beforeContent, afterContent string
+}
+
+// Changes returns the entry changes of the edit.
+func (e Edit) Changes() []Change {
+	return cloneChanges(Diff(e.before, e.after))
+}
+
+// WithHistoryDepth is a HostsOption that sets the number of edits kept in the history, the oldest
+// edits being dropped first. A depth of 0 or less disables the history.
+func WithHistoryDepth(depth int) HostsOption {
+	return func(h *HostsFile) {
+		if depth <= 0 {
+			depth = -1
+		}
+		h.historyDepth = depth
+	}
+}
+
+// maxHistory returns the number of edits kept in the history, 0 if it is disabled.
+func (h *HostsFile) maxHistory() int {
+	switch {
+	case h.historyDepth < 0:
+		return 0
+	case h.historyDepth == 0:
+		return DefaultHistoryDepth
+	default:
+		return h.historyDepth
+	}
+}
+
+// edit starts recording an edit of the named operation and returns the function recording it
+// once the operation is done, typically deferred. An operation changing nothing is not recorded.
+// It must be called with the hosts file locked.
+func (h *HostsFile) edit(operation string) func() {
+	if h.editing {
+		// The operation is part of another one, which records the whole edit
+		return func() {}
+	}
+	h.editing = true
+
+	before, beforeContent := clip(h.entries), h.additionalContent
+	return func() {
+		h.editing = false
+		if h.maxHistory() == 0 {
+			return
+		}
+		if beforeContent == h.additionalContent && Equal(before, h.entries) {
+			return
+		}
+
+		h.undo = append(h.undo, Edit{
+			Operation:     operation,
+			before:        before,
+			after:         clip(h.entries),
+			beforeContent: beforeContent,
+			afterContent:  h.additionalContent,
+		})
+		if len(h.undo) > h.maxHistory() {
+			h.undo = h.undo[len(h.undo)-h.maxHistory():]
+		}
+		// A new edit starts a new branch of the history
+		h.redo = nil
+	}
+}
+
+// clip returns the entries with their capacity clipped to their length, so appending to them
+// reallocates them instead of writing to entries shared with the history or a snapshot.
+func clip(entries []HostEntry) []HostEntry {
+	return entries[:len(entries):len(entries)]
+}
+
+// Undo reverts the last edit of the history, restoring the entries and the additional content
+// as they were before it. The edit can be redone with Redo until a new edit is made.
+// Loading the hosts file clears the history.
+func (h *HostsFile) Undo() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.undo) == 0 {
+		return ErrNothingToUndo
+	}
+
+	edit := h.undo[len(h.undo)-1]
+	h.undo = h.undo[:len(h.undo)-1]
+	h.redo = append(h.redo, edit)
+
+	h.entries = edit.before
+	h.additionalContent = edit.beforeContent
+	return nil
+}
+
+// Redo applies again the last edit reverted by Undo.
+func (h *HostsFile) Redo() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.redo) == 0 {
+		return ErrNothingToRedo
+	}
+
+	edit := h.redo[len(h.redo)-1]
+	h.redo = h.redo[:len(h.redo)-1]
+	h.undo = append(h.undo, edit)
+
+	h.entries = edit.after
+	h.additionalContent = edit.afterContent
+	return nil
+}
+
+// History returns the edits that can be undone, from the oldest to the most recent.
+func (h *HostsFile) History() []Edit {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return append([]Edit(nil), h.undo...)
+}
+
+// clearHistory forgets all the edits, when the content of the hosts file is replaced by Load.
+func (h *HostsFile) clearHistory() {
+	h.undo = nil
+	h.redo = nil
+}
@@ -0,0 +1,230 @@
+package gohosts
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testHistoryHosts = `# header
+127.0.0.1 localhost
+10.0.0.1 api.local www.local
+`
+
+func operations(edits []Edit) []string {
+	var names []string
+	for _, edit := range edits {
+		names = append(names, edit.Operation)
+	}
+	return names
+}
+
+func TestHistory_UndoRedo(t *testing.T) {
+	h, _ := newTestHostsFile(t, testHistoryHosts)
+	original := h.Snapshot().String()
+
+	if err := h.Add("10.0.0.2", []string{"new.local"}, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h.DisableWhere(Hostname("www.local"))
+	if err := h.Remove("127.0.0.1", []string{"localhost"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h.SetAdditionalContent("# changed\n")
+	edited := h.Snapshot().String()
+
+	expected := "Add,DisableWhere,Remove,SetAdditionalContent"
+	if got := strings.Join(operations(h.History()), ","); got != expected {
+		t.Errorf("expected history %s, got %s", expected, got)
+	}
+
+	for i := 0; i < 4; i++ {
+		if err := h.Undo(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if h.Snapshot().String() != original {
+		t.Errorf("unexpected content after undo:\n%s", h.Snapshot().String())
+	}
+	if err := h.Undo(); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("Expected ErrNothingToUndo, got %v", err)
+	}
+	if len(h.History()) != 0 {
+		t.Errorf("expected an empty history, got %v", operations(h.History()))
+	}
+
+	for i := 0; i < 4; i++ {
+		if err := h.Redo(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if h.Snapshot().String() != edited {
+		t.Errorf("unexpected content after redo:\n%s", h.Snapshot().String())
+	}
+	if err := h.Redo(); !errors.Is(err, ErrNothingToRedo) {
+		t.Errorf("Expected ErrNothingToRedo, got %v", err)
+	}
+}
+
+func TestHistory_NewEditClearsRedo(t *testing.T) {
+	h, _ := newTestHostsFile(t, testHistoryHosts)
+
+	if err := h.Add("10.0.0.2", []string{"a.local"}, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := h.Add("10.0.0.3", []string{"b.local"}, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := h.Undo(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The entries restored by the undo are shared with the history, appending to them must
+	// not change the undone edit
+	if err := h.Add("10.0.0.4", []string{"c.local"}, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := h.Redo(); !errors.Is(err, ErrNothingToRedo) {
+		t.Errorf("Expected ErrNothingToRedo after a new edit, got %v", err)
+	}
+
+	if err := h.Undo(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := h.Undo(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := h.Redo(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries := h.Entries()
+	last := entries[len(entries)-1]
+	if len(entries) != 3 || last.IP != "10.0.0.2" {
+		t.Errorf("unexpected entries after redo: %v", entries)
+	}
+}
+
+func TestHistory_Unchanged(t *testing.T) {
+	h, _ := newTestHostsFile(t, testHistoryHosts)
+
+	// Failed and no-op operations are not recorded
+	if err := h.Add("invalid", []string{"a.local"}, ""); err == nil {
+		t.Errorf("Expected an error for an invalid IP address")
+	}
+	if err := h.Remove("10.0.0.9", []string{"missing.local"}); err == nil {
+		t.Errorf("Expected an error for a missing entry")
+	}
+	h.DisableWhere(Hostname("missing.local"))
+	h.Normalize(NormalizeOptions{Lowercase: true})
+	h.SetAdditionalContent(h.AdditionalContent())
+
+	if len(h.History()) != 0 {
+		t.Errorf("expected an empty history, got %v", operations(h.History()))
+	}
+}
+
+func TestHistory_Batch(t *testing.T) {
+	h, _ := newTestHostsFile(t, testHistoryHosts)
+	original := h.Snapshot().String()
+
+	err := h.AddBatch(
+		HostEntry{IP: "10.0.0.2", Hostnames: []string{"a.local"}},
+		HostEntry{IP: "10.0.0.3", Hostnames: []string{"b.local"}},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	history := h.History()
+	if len(history) != 1 || history[0].Operation != "AddBatch" {
+		t.Fatalf("expected a single AddBatch edit, got %v", operations(history))
+	}
+
+	changes := history[0].Changes()
+	if len(changes) != 2 || changes[0].Action != ActionAdd || changes[1].Action != ActionAdd {
+		t.Errorf("unexpected changes: %v", changes)
+	}
+
+	// A batch is undone at once
+	if err := h.Undo(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if h.Snapshot().String() != original {
+		t.Errorf("unexpected content after undo:\n%s", h.Snapshot().String())
+	}
+}
+
+func TestHistory_Depth(t *testing.T) {
+	h, _ := newTestHostsFile(t, testHistoryHosts, WithHistoryDepth(2))
+
+	for _, hostname := range []string{"a.local", "b.local", "c.local"} {
+		if err := h.Add("10.0.0.2", []string{hostname}, ""); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	history := h.History()
+	if len(history) != 2 {
+		t.Fatalf("expected 2 edits, got %d", len(history))
+	}
+	if changes := history[0].Changes(); len(changes) != 1 || changes[0].After.Hostnames[0] != "b.local" {
+		t.Errorf("expected the oldest edit to be dropped, got %v", changes)
+	}
+
+	h, _ = newTestHostsFile(t, testHistoryHosts, WithHistoryDepth(0))
+	if err := h.Add("10.0.0.2", []string{"a.local"}, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := h.Undo(); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("Expected ErrNothingToUndo with the history disabled, got %v", err)
+	}
+}
+
+func TestHistory_Load(t *testing.T) {
+	h, _ := newTestHostsFile(t, testHistoryHosts)
+
+	if err := h.Add("10.0.0.2", []string{"a.local"}, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := h.Load(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := h.Undo(); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("Expected ErrNothingToUndo after a load, got %v", err)
+	}
+}
+
+func TestHistory_Apply(t *testing.T) {
+	h, _ := newTestHostsFile(t, testHistoryHosts)
+	h.autoPrune = true
+	h.entries = append(h.entries, HostEntry{
+		IP:        "10.0.0.9",
+		Hostnames: []string{"old.local"},
+		Active:    true,
+		Expires:   time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+
+	plan, err := h.Plan(Scope{Block: "dev"}, HostEntry{IP: "10.0.0.2", Hostnames: []string{"a.local"}, Active: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	before := h.Snapshot().String()
+	if err := h.Apply(plan); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The pruning done by the save is part of the Apply edit
+	history := h.History()
+	if len(history) != 1 || history[0].Operation != "Apply" {
+		t.Fatalf("expected a single Apply edit, got %v", operations(history))
+	}
+	if err := h.Undo(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if h.Snapshot().String() != before {
+		t.Errorf("unexpected content after undo:\n%s", h.Snapshot().String())
+	}
+}
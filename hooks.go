@@ -0,0 +1,178 @@
+package gohosts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// HookType is the point of the hosts file lifecycle a hook runs at.
+type HookType string
+
+const (
+	// HookBeforeSave hooks run before Save writes the hosts file, an error vetoes the save.
+	HookBeforeSave HookType = "before-save"
+	// HookAfterSave hooks run after Save wrote the hosts file.
+	HookAfterSave HookType = "after-save"
+	// HookAfterLoad hooks run after Load read the hosts file.
+	HookAfterLoad HookType = "after-load"
+	// HookRestore hooks run after RestoreBackup restored the hosts file.
+	HookRestore HookType = "restore"
+)
+
+// HookEvent describes the change of the hosts file a hook runs for.
+type HookEvent struct {
+	Type HookType `json:"type"`
+	Path string   `json:"path"`
+	// Changes are the entry changes of the event: the changes written by a save, the changes of
+	// a restore, or the changes from the entries held before a load to the entries loaded.
+	Changes []Change `json:"changes"`
+	// Backup is the filename of the backup created by a save or restored by a restore.
+	Backup string `json:"backup,omitempty"`
+}
+
+// Hook is a function run at a point of the hosts file lifecycle.
+type Hook func(event HookEvent) error
+
+// OnBeforeSave registers a hook run before Save writes the hosts file, with the changes about to be
+// written. If the hook returns an error, the hosts file is not written and Save returns the error.
+// The hook runs with the hosts file locked, it must not call the methods of the hosts file.
+func (h *HostsFile) OnBeforeSave(hook Hook) {
+	h.addHook(HookBeforeSave, hook)
+}
+
+// OnAfterSave registers a hook run after Save wrote the hosts file, with the changes written and
+// the backup created.
+func (h *HostsFile) OnAfterSave(hook Hook) {
+	h.addHook(HookAfterSave, hook)
+}
+
+// OnAfterLoad registers a hook run after Load read the hosts file, with the changes from the entries
+// held before the load to the entries loaded.
+func (h *HostsFile) OnAfterLoad(hook Hook) {
+	h.addHook(HookAfterLoad, hook)
+}
+
+// OnRestore registers a hook run after RestoreBackup restored the hosts file, with the changes of the
+// restore and the backup restored.
+func (h *HostsFile) OnRestore(hook Hook) {
+	h.addHook(HookRestore, hook)
+}
+
+// addHook registers a hook of the provided type, hooks run in the order they were registered.
+func (h *HostsFile) addHook(hookType HookType, hook Hook) {
+	h.hooksMu.Lock()
+	defer h.hooksMu.Unlock()
+
+	if h.hooks == nil {
+		h.hooks = make(map[HookType][]Hook)
+	}
+	h.hooks[hookType] = append(h.hooks[hookType], hook)
+}
+
+// hasHooks checks if hooks of the provided type are registered, or hooks of any type if none is provided.
+func (h *HostsFile) hasHooks(hookType ...HookType) bool {
+	h.hooksMu.Lock()
+	defer h.hooksMu.Unlock()
+
+	if len(hookType) == 0 {
+		return len(h.hooks) > 0
+	}
+	return len(h.hooks[hookType[0]]) > 0
+}
+
+// runHooks runs the hooks of the provided type. Before save hooks stop at the first error, the
+// other hooks all run and their errors are joined.
+func (h *HostsFile) runHooks(hookType HookType, changes []Change, backup string) error {
+	h.hooksMu.Lock()
+	hooks := append([]Hook(nil), h.hooks[hookType]...)
+	h.hooksMu.Unlock()
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	// Hooks may run after the hosts file is unlocked, they get their own copy of the changes
+	event := HookEvent{Type: hookType, Path: h.path, Changes: cloneChanges(changes), Backup: backup}
+
+	var errs []error
+	for _, hook := range hooks {
+		err := hook(event)
+		if err == nil {
+			continue
+		}
+		if hookType == HookBeforeSave {
+			return fmt.Errorf("save vetoed by hook: %w", err)
+		}
+		errs = append(errs, err)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("%s hook failed: %w", hookType, err)
+	}
+	return nil
+}
+
+// CommandHook is a hook executing an external command, such as flushing the resolver cache with
+// "resolvectl flush-caches". Register it with the Run method:
+//
+//	h.OnAfterSave(gohosts.CommandHook{Name: "resolvectl", Args: []string{"flush-caches"}, Timeout: 5 * time.Second}.Run)
+//
+// The command receives the event as JSON on its standard input, and the GOHOSTS_EVENT, GOHOSTS_PATH
+// and GOHOSTS_BACKUP environment variables.
+type CommandHook struct {
+	Name string
+	Args []string
+	// Timeout is the time after which the command is killed, 0 for no timeout.
+	Timeout time.Duration
+	// Output receives the combined standard output and standard error of the command, if set.
+	Output io.Writer
+}
+
+// Run runs the command for the event. It fails if the command cannot be started, times out or
+// exits with a non-zero status, the error then includes the output of the command.
+func (c CommandHook) Run(event HookEvent) error {
+	input, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.Name, c.Args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	// Do not wait for the children of a killed command still holding the output open
+	cmd.WaitDelay = time.Second
+	cmd.Env = append(os.Environ(),
+		"GOHOSTS_EVENT="+string(event.Type),
+		"GOHOSTS_PATH="+event.Path,
+		"GOHOSTS_BACKUP="+event.Backup,
+	)
+
+	err = cmd.Run()
+	if c.Output != nil {
+		c.Output.Write(output.Bytes())
+	}
+
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("command %s timed out after %v: %w: %s", c.Name, c.Timeout, ctx.Err(), strings.TrimSpace(output.String()))
+	case err != nil:
+		return fmt.Errorf("command %s failed: %w: %s", c.Name, err, strings.TrimSpace(output.String()))
+	}
+	return nil
+}
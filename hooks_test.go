@@ -0,0 +1,214 @@
+package gohosts
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testHooksHosts = "127.0.0.1 localhost\n10.0.0.1 api.local\n"
+
+func TestHooks_Save(t *testing.T) {
+	h, m := newTestHostsFile(t, testHooksHosts)
+
+	var events []HookEvent
+	record := func(event HookEvent) error {
+		events = append(events, event)
+		return nil
+	}
+	h.OnAfterLoad(record)
+	h.OnBeforeSave(record)
+	h.OnAfterSave(record)
+
+	// Reload the hosts file changed on disk
+	if err := m.WriteFile("/etc/hosts", []byte(testHooksHosts+"10.0.0.3 db.local\n"), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := h.Load(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := h.Add("10.0.0.2", []string{"web.local"}, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := h.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %v", events)
+	}
+
+	// The load reports the entry added on disk
+	if events[0].Type != HookAfterLoad || len(events[0].Changes) != 1 || events[0].Changes[0].After.IP != "10.0.0.3" {
+		t.Errorf("unexpected load event: %v", events[0])
+	}
+	for _, event := range events[1:] {
+		if len(event.Changes) != 1 || event.Changes[0].Action != ActionAdd || event.Changes[0].After.IP != "10.0.0.2" {
+			t.Errorf("unexpected changes: %v", event.Changes)
+		}
+		if event.Path != "/etc/hosts" {
+			t.Errorf("unexpected path: %s", event.Path)
+		}
+	}
+	if events[1].Type != HookBeforeSave || events[1].Backup != "" {
+		t.Errorf("unexpected before save event: %v", events[1])
+	}
+	if events[2].Type != HookAfterSave || events[2].Backup != "hosts_gohosts_20240101120000.bak" {
+		t.Errorf("unexpected after save event: %v", events[2])
+	}
+
+	// Loading the saved file changes nothing
+	events = nil
+	if err := h.Load(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events) != 1 || len(events[0].Changes) != 0 {
+		t.Errorf("unexpected events: %v", events)
+	}
+
+	data, _ := m.ReadFile("/etc/hosts")
+	if !strings.Contains(string(data), "web.local") {
+		t.Errorf("unexpected content: %q", data)
+	}
+}
+
+func TestHooks_FirstLoad(t *testing.T) {
+	_, m := newTestHostsFile(t, testHooksHosts)
+	h, err := New(WithFS(m), WithPath("/etc/hosts"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var changes []Change
+	h.OnAfterLoad(func(event HookEvent) error {
+		changes = event.Changes
+		return nil
+	})
+	if err := h.Load(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The first load adds every entry
+	if len(changes) != 2 || changes[0].Action != ActionAdd || changes[1].Action != ActionAdd {
+		t.Errorf("unexpected changes: %v", changes)
+	}
+}
+
+func TestHooks_Veto(t *testing.T) {
+	h, m := newTestHostsFile(t, testHooksHosts)
+	if err := h.Load(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	saved := false
+	h.OnBeforeSave(func(event HookEvent) error {
+		for _, change := range event.Changes {
+			if change.Action == ActionRemove {
+				return errors.New("entries cannot be removed")
+			}
+		}
+		return nil
+	})
+	h.OnAfterSave(func(event HookEvent) error {
+		saved = true
+		return nil
+	})
+
+	if err := h.Remove("10.0.0.1", []string{"api.local"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err := h.Save()
+	if err == nil || !strings.Contains(err.Error(), "entries cannot be removed") {
+		t.Errorf("Expected an error for a vetoed save, got %v", err)
+	}
+	if saved {
+		t.Errorf("expected the after save hooks not to run")
+	}
+
+	data, _ := m.ReadFile("/etc/hosts")
+	if !strings.Contains(string(data), "api.local") {
+		t.Errorf("expected the hosts file to be unchanged, got %q", data)
+	}
+	if backups, _ := getBackupFiles(m, "/etc/hosts"); len(backups) != 0 {
+		t.Errorf("expected no backup, got %v", backups)
+	}
+}
+
+func TestHooks_Restore(t *testing.T) {
+	h, _ := newTestHostsFile(t, testHooksHosts)
+	if err := h.Load(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := h.Add("10.0.0.2", []string{"web.local"}, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := h.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var events []HookEvent
+	h.OnRestore(func(event HookEvent) error {
+		events = append(events, event)
+		return nil
+	})
+	// After hooks all run, their errors are returned
+	h.OnRestore(func(event HookEvent) error {
+		return errors.New("cache flush failed")
+	})
+
+	err := h.RestoreBackup()
+	if err == nil || !strings.Contains(err.Error(), "cache flush failed") {
+		t.Errorf("Expected an error for a failed restore hook, got %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %v", events)
+	}
+	if events[0].Backup != "hosts_gohosts_20240101120000.bak" {
+		t.Errorf("unexpected backup: %s", events[0].Backup)
+	}
+	if len(events[0].Changes) != 1 || events[0].Changes[0].Action != ActionRemove {
+		t.Errorf("unexpected changes: %v", events[0].Changes)
+	}
+}
+
+func TestCommandHook(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	event := HookEvent{Type: HookAfterSave, Path: "/etc/hosts", Backup: "hosts.bak"}
+
+	var output bytes.Buffer
+	hook := CommandHook{
+		Name:   "sh",
+		Args:   []string{"-c", `echo "$GOHOSTS_EVENT $GOHOSTS_PATH $GOHOSTS_BACKUP"; cat`},
+		Output: &output,
+	}
+	if err := hook.Run(event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(output.String(), "after-save /etc/hosts hosts.bak\n{") {
+		t.Errorf("unexpected output: %q", output.String())
+	}
+
+	hook = CommandHook{Name: "sh", Args: []string{"-c", "echo flush failed; exit 3"}}
+	err := hook.Run(event)
+	if err == nil || !strings.Contains(err.Error(), "flush failed") {
+		t.Errorf("Expected an error with the output of a failing command, got %v", err)
+	}
+
+	hook = CommandHook{Name: "sh", Args: []string{"-c", "exec sleep 5"}, Timeout: 50 * time.Millisecond}
+	err = hook.Run(event)
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Expected an error for a command timing out, got %v", err)
+	}
+
+	hook = CommandHook{Name: "gohosts-missing-command"}
+	if err := hook.Run(event); err == nil {
+		t.Errorf("Expected an error for a missing command")
+	}
+}
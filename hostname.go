@@ -0,0 +1,86 @@
+package gohosts
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// HostnameValidator checks if a hostname is valid. Hostnames are checked once normalized:
+// in their ASCII form, lowercase and without trailing dot.
+type HostnameValidator func(hostname string) bool
+
+// dnsLabelRegexp matches a DNS label, which may contain underscores unlike a hostname label.
+var dnsLabelRegexp = regexp.MustCompile(`^[a-z0-9_](?:[a-z0-9_-]{0,61}[a-z0-9_])?$`)
+
+// WithHostnameValidator is a HostsOption that sets the validator used to check the hostnames
+// given to Add, Remove and Plan, and reported by Lint. StrictHostname is used by default.
+func WithHostnameValidator(v HostnameValidator) HostsOption {
+	return func(h *HostsFile) {
+		h.hostnameValidator = v
+	}
+}
+
+// StrictHostname is a HostnameValidator accepting the hostnames following RFC 1123: dot
+// separated labels of letters, digits and hyphens, not starting or ending with a hyphen.
+func StrictHostname(hostname string) bool {
+	return isValidHostname(hostname)
+}
+
+// DNSHostname is a HostnameValidator accepting RFC 1123 hostnames whose labels may also
+// contain underscores, such as "_acme-challenge.example.com".
+func DNSHostname(hostname string) bool {
+	if len(hostname) == 0 || len(hostname) > 255 {
+		return false
+	}
+
+	for _, label := range strings.Split(hostname, ".") {
+		if !dnsLabelRegexp.MatchString(strings.ToLower(label)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// PermissiveHostname is a HostnameValidator accepting any hostname glibc reads from a hosts file,
+// that is any name without whitespace or comment character, wildcard placeholders included.
+func PermissiveHostname(hostname string) bool {
+	return len(hostname) > 0 && !strings.ContainsAny(hostname, " \t\r\n#")
+}
+
+// normalizeHostname returns the hostname lowercase and without trailing dot, the form hostnames
+// are stored in.
+func normalizeHostname(hostname string) string {
+	if len(hostname) > 1 {
+		hostname = strings.TrimSuffix(hostname, ".")
+	}
+	return strings.ToLower(hostname)
+}
+
+// hostnameKey returns the normalized ASCII form of the hostname, used to compare hostnames.
+func hostnameKey(hostname string) string {
+	return normalizeHostname(asciiHostname(hostname))
+}
+
+// validHostname checks the hostname with the validator of the hosts file.
+func (h *HostsFile) validHostname(hostname string) bool {
+	if h.hostnameValidator == nil {
+		return StrictHostname(hostname)
+	}
+	return h.hostnameValidator(hostname)
+}
+
+// normalizeHostnames returns the provided hostnames normalized, internationalized hostnames being
+// converted to punycode, and checks each of them with the validator of the hosts file.
+func (h *HostsFile) normalizeHostnames(hostnames []string) ([]string, error) {
+	normalized := make([]string, len(hostnames))
+	for i, hostname := range hostnames {
+		ascii, err := ToASCII(hostname)
+		if err != nil || !h.validHostname(normalizeHostname(ascii)) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidHostname, hostname)
+		}
+		normalized[i] = normalizeHostname(ascii)
+	}
+	return normalized, nil
+}
@@ -0,0 +1,122 @@
+package gohosts
+
+import (
+	"testing"
+)
+
+func TestHostnameValidators(t *testing.T) {
+	tests := []struct {
+		hostname   string
+		strict     bool
+		dns        bool
+		permissive bool
+	}{
+		{"api.local", true, true, true},
+		{"localhost", true, true, true},
+		{"_acme-challenge.local", false, true, true},
+		{"my_host", false, true, true},
+		{"*.local", false, false, true},
+		{"-bad.local", false, false, true},
+		{"a-label-that-is-longer-than-the-sixty-three-characters-a-label-may-have", false, false, true},
+		{"", false, false, false},
+		{"two words", false, false, false},
+		{"api.local#comment", false, false, false},
+	}
+
+	for _, test := range tests {
+		if got := StrictHostname(test.hostname); got != test.strict {
+			t.Errorf("StrictHostname(%q) = %t, expected %t", test.hostname, got, test.strict)
+		}
+		if got := DNSHostname(test.hostname); got != test.dns {
+			t.Errorf("DNSHostname(%q) = %t, expected %t", test.hostname, got, test.dns)
+		}
+		if got := PermissiveHostname(test.hostname); got != test.permissive {
+			t.Errorf("PermissiveHostname(%q) = %t, expected %t", test.hostname, got, test.permissive)
+		}
+	}
+}
+
+func TestParseHosts_NormalizesHostnames(t *testing.T) {
+	h := &HostsFile{}
+
+	entries, err := h.parseHosts([]string{"10.0.0.1 API.local. Web.Local", "# 10.0.0.1 Is The Gateway"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Disabled lines may be prose, they are kept as written
+	expected := []HostEntry{
+		{IP: "10.0.0.1", Hostnames: []string{"api.local", "web.local"}, Active: true},
+		{IP: "10.0.0.1", Hostnames: []string{"Is", "The", "Gateway"}},
+	}
+	if !Equal(entries, expected) {
+		t.Errorf("Entries do not match expected entries. \nGot: %v, \nExpected: %v", entries, expected)
+	}
+}
+
+func TestAdd_HostnameValidator(t *testing.T) {
+	h := &HostsFile{}
+
+	err := h.Add("10.0.0.1", []string{"_acme-challenge.local"}, "")
+	if err == nil {
+		t.Errorf("Expected an error for an underscore with the strict validator")
+	}
+
+	h = &HostsFile{}
+	WithHostnameValidator(DNSHostname)(h)
+
+	err = h.Add("10.0.0.1", []string{"_acme-challenge.local", "Foo.Local."}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = h.Add("10.0.0.2", []string{"*.local"}, "")
+	if err == nil {
+		t.Errorf("Expected an error for a wildcard with the DNS validator")
+	}
+
+	expected := []HostEntry{
+		{IP: "10.0.0.1", Hostnames: []string{"_acme-challenge.local", "foo.local"}, Active: true},
+	}
+	if !Equal(h.entries, expected) {
+		t.Errorf("Entries do not match expected entries. \nGot: %v, \nExpected: %v", h.entries, expected)
+	}
+
+	// Remove matches regardless of case and trailing dot
+	err = h.Remove("10.0.0.1", []string{"FOO.local."})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected = []HostEntry{
+		{IP: "10.0.0.1", Hostnames: []string{"_acme-challenge.local"}, Active: true},
+	}
+	if !Equal(h.entries, expected) {
+		t.Errorf("Entries do not match expected entries. \nGot: %v, \nExpected: %v", h.entries, expected)
+	}
+	// The same hostname named twice only removes it, not the whole entry
+	if err := h.Add("10.0.0.3", []string{"a.local", "b.local"}, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := h.Remove("10.0.0.3", []string{"a.local", "A.local"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected = append(expected, HostEntry{IP: "10.0.0.3", Hostnames: []string{"b.local"}, Active: true})
+	if !Equal(h.entries, expected) {
+		t.Errorf("Entries do not match expected entries. \nGot: %v, \nExpected: %v", h.entries, expected)
+	}
+}
+
+func TestLint_HostnameValidator(t *testing.T) {
+	h, _ := newTestHostsFile(t, "127.0.0.1 localhost\n::1 localhost\n10.0.0.1 _acme-challenge.local *.local\n")
+
+	findings := Lint(h)
+	if len(findings) != 2 {
+		t.Errorf("expected 2 invalid hostnames with the strict validator, got %v", findings)
+	}
+
+	h.hostnameValidator = PermissiveHostname
+	findings = Lint(h)
+	if len(findings) != 0 {
+		t.Errorf("expected no findings with the permissive validator, got %v", findings)
+	}
+}
@@ -2,24 +2,101 @@ package gohosts
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
-	"os"
-	"strings"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sync"
+	"time"
 )
 
 // HostEntry represents a single entry in a hosts file.
 type HostEntry struct {
-	IP        string
-	Hostnames []string
-	Comment   string
-	Active    bool
+	// Line is the 1-based line number the entry was parsed from, 0 if the entry
+	// was not read from a file.
+	Line      int      `json:"line" yaml:"line"`
+	IP        string   `json:"ip" yaml:"ip"`
+	Hostnames []string `json:"hostnames" yaml:"hostnames"`
+	// Comment is the human text of the inline comment, without the metadata tags.
+	Comment string `json:"comment,omitempty" yaml:"comment,omitempty"`
+	// Tags are the key=value metadata tags of the inline comment.
+	Tags   map[string]string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Active bool              `json:"active" yaml:"active"`
+	// Expires is the time after which the entry is considered expired, zero if the
+	// entry does not expire.
+	Expires time.Time `json:"expires,omitzero" yaml:"expires,omitempty"`
+	// Block is the name of the managed block the entry belongs to, empty if the
+	// entry is not part of any block.
+	Block string `json:"block" yaml:"block"`
 }
 
-// HostsFile represents a hosts file.
+// HostsFile represents a hosts file. It is safe for concurrent use by multiple goroutines,
+// its entries are read and replaced through copies, see Entries and SetEntries.
 type HostsFile struct {
-	path             string
-	Entries          []HostEntry
-	AditionalContent string
+	path              string
+	createIfMissing   bool
+	fsys              FS
+	clock             Clock
+	profileDir        string
+	autoPrune         bool
+	formatter         Formatter
+	conflictPolicy    ConflictPolicy
+	hostnameValidator HostnameValidator
+	ipv4MappedPolicy  IPv4MappedPolicy
+	auditLog          string
+	auditReason       string
+	historyDepth      int
+
+	hooksMu sync.Mutex
+	hooks   map[HookType][]Hook
+
+	// mu guards the fields below, and the hosts file and its backups on disk
+	mu                sync.RWMutex
+	encoding          Encoding
+	skipped           []skippedLine
+	entries           []HostEntry
+	additionalContent string
+	editing           bool
+	undo              []Edit
+	redo              []Edit
+}
+
+// Entries returns a copy of the host entries, in order. Changing the returned entries does not
+// change the hosts file, use SetEntries to replace them.
+func (h *HostsFile) Entries() []HostEntry {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return cloneEntries(h.entries)
+}
+
+// SetEntries replaces the host entries with a copy of the provided entries, as they are.
+func (h *HostsFile) SetEntries(entries []HostEntry) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	defer h.edit("SetEntries")()
+
+	h.entries = cloneEntries(entries)
+}
+
+// AdditionalContent returns the content of the hosts file that is not host entries, such as
+// comments and blank lines, written before the entries.
+func (h *HostsFile) AdditionalContent() string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.additionalContent
+}
+
+// SetAdditionalContent replaces the content written before the host entries.
+func (h *HostsFile) SetAdditionalContent(content string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	defer h.edit("SetAdditionalContent")()
+
+	h.additionalContent = content
 }
 
 // HostsOption is a functional option for configuring a HostsFile.
@@ -32,6 +109,15 @@ func WithPath(path string) HostsOption {
 	}
 }
 
+// WithCreateIfMissing is a HostsOption that allows the hosts file not to exist yet. A missing hosts
+// file is loaded with the default entries, mapping localhost to 127.0.0.1 and ::1, and created on
+// the first Save.
+func WithCreateIfMissing() HostsOption {
+	return func(h *HostsFile) {
+		h.createIfMissing = true
+	}
+}
+
 // New creates a new HostsFile with the provided options.
 // If no options are provided, the system hosts file is used.
 func New(opts ...HostsOption) (*HostsFile, error) {
@@ -48,87 +134,265 @@ func New(opts ...HostsOption) (*HostsFile, error) {
 		opt(h)
 	}
 
-	if !isValidPath(h.path) {
-		return nil, fmt.Errorf("hosts file does not exist: %s", h.path)
+	if !isValidPath(h.filesystem(), h.path) {
+		if !h.createIfMissing {
+			return nil, &PathError{Op: "open", Path: h.path, Err: fs.ErrNotExist}
+		}
+		h.entries = defaultEntries()
 	}
 
 	return h, nil
 }
 
+// Parse creates a HostsFile from the hosts file content read from r. The HostsFile has no backing
+// path unless WithPath is provided, its content can be written with WriteTo or SaveAs.
+func Parse(r io.Reader, opts ...HostsOption) (*HostsFile, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hosts file: %w", err)
+	}
+
+	return ParseBytes(content, opts...)
+}
+
+// ParseBytes creates a HostsFile from the provided hosts file content, see Parse.
+func ParseBytes(content []byte, opts ...HostsOption) (*HostsFile, error) {
+	h := &HostsFile{}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	lines, err := h.splitLines(content)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.load(lines); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// defaultEntries returns the entries of a hosts file created by gohosts.
+func defaultEntries() []HostEntry {
+	return []HostEntry{
+		{IP: "127.0.0.1", Hostnames: []string{"localhost"}, Active: true},
+		{IP: "::1", Hostnames: []string{"localhost"}, Active: true},
+	}
+}
+
 // Load reads the hosts file and parses its content.
 func (h *HostsFile) Load() error {
-	lines, err := h.readHosts()
+	if h.path == "" {
+		return ErrNoPath
+	}
+
+	h.mu.Lock()
+	changes, err := h.loadFile()
+	h.mu.Unlock()
 	if err != nil {
 		return err
 	}
 
+	return h.runHooks(HookAfterLoad, changes, "")
+}
+
+// loadFile reads the hosts file and replaces the content of the HostsFile with it. It returns
+// the changes from the previous entries for the after load hooks, nil if there are none.
+func (h *HostsFile) loadFile() ([]Change, error) {
+	// The entries before the load, to compute the changes for the hooks
+	previous := h.entries
+
+	lines, err := h.readHosts()
+	switch {
+	case err != nil && h.createIfMissing && errors.Is(err, fs.ErrNotExist):
+		// The hosts file is created on save
+		h.additionalContent = ""
+		h.encoding = Encoding{}
+		h.skipped = nil
+		h.entries = defaultEntries()
+	case err != nil:
+		return nil, &PathError{Op: "load", Path: h.path, Err: err}
+	default:
+		if err := h.load(lines); err != nil {
+			return nil, err
+		}
+	}
+
+	// The edits made before the load do not apply to the new content
+	h.clearHistory()
+
+	if !h.hasHooks(HookAfterLoad) {
+		return nil, nil
+	}
+	return Diff(previous, h.entries), nil
+}
+
+// load parses the lines of the hosts file and replaces the content of the HostsFile with them.
+func (h *HostsFile) load(lines []string) error {
+	// The additional content is rebuilt from the file
+	h.additionalContent = ""
 	entries, err := h.parseHosts(lines)
 	if err != nil {
 		return err
 	}
 
-	h.Entries = entries
+	h.entries = entries
+
+	if h.autoPrune {
+		h.pruneExpired(h.now())
+	}
 
 	return nil
 }
 
 // Save writes the hosts file with the modified content. It creates a backup of the original hosts file
-// before writing the modified content.
+// before writing the modified content. A missing hosts file is created if WithCreateIfMissing is set.
 func (h *HostsFile) Save() error {
+	return h.SaveWithReason(h.auditReason)
+}
+
+// SaveWithReason saves the hosts file like Save, and records the provided reason for the save
+// in the audit log.
+func (h *HostsFile) SaveWithReason(reason string) error {
+	h.mu.Lock()
+	changes, backup, auditErr, err := h.save(reason)
+	h.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// The hosts file was written even if the audit log was not, the hooks run anyway
+	return errors.Join(auditErr, h.runHooks(HookAfterSave, changes, backup))
+}
+
+// save writes the hosts file and records the save in the audit log with the provided reason. It returns the changes
+// written and the backup created, for the after save hooks. A failure to write the audit log is
+// returned as auditErr, separately from err, as the hosts file was written anyway.
+func (h *HostsFile) save(reason string) (changes []Change, backup string, auditErr, err error) {
+	if h.path == "" {
+		return nil, "", nil, fmt.Errorf("%w, use SaveAs or WriteTo", ErrNoPath)
+	}
+
+	h.autoPruneExpired()
+
+	exists := isValidPath(h.filesystem(), h.path)
+	if !exists {
+		if !h.createIfMissing {
+			return nil, "", nil, &PathError{Op: "save", Path: h.path, Err: fs.ErrNotExist}
+		}
+		// There is nothing to back up, and nothing to restore on failure
+		if err := h.filesystem().MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+			return nil, "", nil, &PathError{Op: "create", Path: h.path, Err: err}
+		}
+		changes = Diff(nil, h.entries)
+		if err := h.runHooks(HookBeforeSave, changes, ""); err != nil {
+			return nil, "", nil, err
+		}
+		if err := h.writeHosts(false); err != nil {
+			return nil, "", nil, err
+		}
+		return changes, "", h.audit(AuditSave, changes, "", "", reason), nil
+	}
+
+	// The entries on disk, to compute the changes of the save
+	before, err := h.diskEntries()
+	if err != nil {
+		return nil, "", nil, err
+	}
+	changes = Diff(before, h.entries)
+	if err := h.runHooks(HookBeforeSave, changes, ""); err != nil {
+		return nil, "", nil, err
+	}
+
 	// Before doing anything, create a backup of the hosts file
-	err := h.CreateBackup()
+	backup, err = h.createBackup()
 	if err != nil {
-		return fmt.Errorf("failed to create backup: %v", err)
+		return nil, "", nil, err
 	}
 
+	if err := h.writeHosts(true); err != nil {
+		return nil, "", nil, err
+	}
+	return changes, backup, h.audit(AuditSave, changes, backup, "", reason), nil
+}
+
+// writeHosts writes the content of the HostsFile to its path. If restore is true, the latest
+// backup is restored on failure.
+func (h *HostsFile) writeHosts(restore bool) error {
 	// Open the hosts file for writing
-	file, err := os.OpenFile(h.path, os.O_WRONLY|os.O_TRUNC, 0644)
+	file, err := h.filesystem().Create(h.path)
 	if err != nil {
-		return fmt.Errorf("failed to open hosts file: %v", err)
+		return &PathError{Op: "save", Path: h.path, Err: err}
 	}
 	defer file.Close()
 
-	// Start by writing the additional content to the file
-	// This is the content that was not parsed as host entries
+	// Write the additional content followed by the host entries
 	writer := bufio.NewWriter(file)
-	_, err = writer.WriteString(h.AditionalContent)
+	err = h.format(writer)
+	if err != nil && !restore {
+		return fmt.Errorf("failed to write hosts file: %w", err)
+	}
 	if err != nil {
 		// If an error occurs while writing, restore the backup
-		if restoreErr := h.RestoreBackup(); restoreErr != nil {
-			return fmt.Errorf("failed to write additional content to hosts file: %v, and failed to restore backup: %v", err, restoreErr)
-		}
-		return fmt.Errorf("failed to write additional content to hosts file: %v", err)
-	}
-
-	for _, entry := range h.Entries {
-		// TODO: make a constant for the spacing between the columns
-		// TODO: also maybe pretty print the entries
-		line := fmt.Sprintf("%s     %s", entry.IP, strings.Join(entry.Hostnames, " "))
-		if entry.Comment != "" {
-			line += "     # " + entry.Comment
-		}
-		if !entry.Active {
-			line = "# " + line
-		}
-		// Then write the host entry to the file
-		_, err := writer.WriteString(line + "\n")
-		if err != nil {
-			// If an error occurs while writing, restore the backup
-			if restoreErr := h.RestoreBackup(); restoreErr != nil {
-				return fmt.Errorf("failed to write entry to hosts file: %v, and failed to restore backup: %v", err, restoreErr)
-			}
-			return fmt.Errorf("failed to write entry to hosts file: %v", err)
+		if _, restoreErr := h.restoreBackup(1); restoreErr != nil {
+			return fmt.Errorf("failed to write hosts file: %w, and failed to restore backup: %w", err, restoreErr)
 		}
+		return fmt.Errorf("failed to write hosts file: %w", err)
 	}
 
 	err = writer.Flush()
+	if err != nil && !restore {
+		return fmt.Errorf("failed to flush writer: %w", err)
+	}
 	if err != nil {
 		// If an error occurs while flushing, restore the backup
-		if restoreErr := h.RestoreBackup(); restoreErr != nil {
-			return fmt.Errorf("failed to flush writer: %v, and failed to restore backup: %v", err, restoreErr)
+		if _, restoreErr := h.restoreBackup(1); restoreErr != nil {
+			return fmt.Errorf("failed to flush writer: %w, and failed to restore backup: %w", err, restoreErr)
 		}
-		return fmt.Errorf("failed to flush writer: %v", err)
+		return fmt.Errorf("failed to flush writer: %w", err)
 	}
 
 	return nil
 }
+
+// WriteTo writes the hosts file content, as it would be saved, to w. It implements the io.WriterTo interface.
+func (h *HostsFile) WriteTo(w io.Writer) (int64, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	counter := &countingWriter{w: w}
+	err := h.format(counter)
+	return counter.n, err
+}
+
+// SaveAs writes the hosts file content to the provided path, creating or replacing the file.
+// Unlike Save, no backup is created and the path used by Load and Save is unchanged.
+func (h *HostsFile) SaveAs(path string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.autoPruneExpired()
+
+	var b bytes.Buffer
+	if err := h.format(&b); err != nil {
+		return fmt.Errorf("failed to write hosts file: %w", err)
+	}
+
+	if err := writeFileAtomic(h.filesystem(), path, b.Bytes()); err != nil {
+		return fmt.Errorf("failed to write hosts file: %w", err)
+	}
+	return nil
+}
+
+// countingWriter is an io.Writer counting the bytes written to the underlying writer.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -1,10 +1,45 @@
 package gohosts
 
 import (
+	"bufio"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
+// fixedClock is a Clock always returning the same time.
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time { return time.Time(c) }
+
+// testTime is the time of the clock of the hosts files created by newTestHostsFile.
+var testTime = time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+// newTestHostsFile creates and loads a hosts file with the provided content at /etc/hosts, in an
+// in-memory filesystem, with a clock fixed at testTime and the profiles in /profiles. The options
+// are applied after these ones.
+func newTestHostsFile(t testing.TB, content string, opts ...HostsOption) (*HostsFile, *MemFS) {
+	t.Helper()
+
+	m := NewMemFS()
+	if err := m.WriteFile("/etc/hosts", []byte(content), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	opts = append([]HostsOption{WithFS(m), WithPath("/etc/hosts"), WithClock(fixedClock(testTime)), WithProfileDir("/profiles")}, opts...)
+	h, err := New(opts...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := h.Load(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return h, m
+}
+
 func TestNewHosts_Default(t *testing.T) {
 	h, err := New()
 	if err != nil {
@@ -76,12 +111,12 @@ func TestLoad_Entries(t *testing.T) {
 		t.Errorf("failed to load hosts file: %v", err)
 	}
 
-	if len(h.Entries) != 0 {
-		t.Errorf("expected 0 entries, but got %d", len(h.Entries))
+	if len(h.entries) != 0 {
+		t.Errorf("expected 0 entries, but got %d", len(h.entries))
 	}
 }
 
-func TestLoad_CorruptedFile(t *testing.T) {
+func TestLoad_LongGarbageLine(t *testing.T) {
 	tempFile, err := os.CreateTemp("", "courpted")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -104,8 +139,104 @@ func TestLoad_CorruptedFile(t *testing.T) {
 	}
 
 	err = h.Load()
-	if err == nil {
-		t.Error("expected an error, but got nil")
+	if err != nil {
+		t.Errorf("failed to load hosts file: %v", err)
+	}
+
+	if len(h.entries) != 0 {
+		t.Errorf("expected 0 entries, but got %d", len(h.entries))
+	}
+}
+
+// writeLongLineFixture writes a hosts file with a single entry whose line is longer than
+// the 64 KiB token limit of bufio.Scanner, and returns its path and hostnames.
+func writeLongLineFixture(t *testing.T) (string, []string) {
+	t.Helper()
+
+	var hostnames []string
+	for i := 0; i < 5000; i++ {
+		hostnames = append(hostnames, fmt.Sprintf("host%04d.generated.example.com", i))
+	}
+
+	path := filepath.Join(t.TempDir(), "hosts")
+	content := "# generated\n127.0.0.1 localhost\n10.0.0.1 " + strings.Join(hostnames, " ") + "\n"
+	if len(content) <= bufio.MaxScanTokenSize {
+		t.Fatalf("expected the fixture to exceed %d bytes, got %d", bufio.MaxScanTokenSize, len(content))
+	}
+
+	err := os.WriteFile(path, []byte(content), 0644)
+	if err != nil {
+		t.Fatalf("failed to write hosts file: %v", err)
+	}
+
+	return path, hostnames
+}
+
+func TestLoad_LongLine(t *testing.T) {
+	path, hostnames := writeLongLineFixture(t)
+
+	h, err := New(WithPath(path))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = h.Load()
+	if err != nil {
+		t.Fatalf("failed to load hosts file: %v", err)
+	}
+
+	if len(h.entries) != 2 {
+		t.Fatalf("expected 2 entries, but got %d", len(h.entries))
+	}
+	if !equalStrings(h.entries[1].Hostnames, hostnames) {
+		t.Errorf("expected %d hostnames, but got %d", len(hostnames), len(h.entries[1].Hostnames))
+	}
+}
+
+func TestSave_MaxLineLength(t *testing.T) {
+	path, hostnames := writeLongLineFixture(t)
+
+	h, err := New(WithPath(path), WithFormatter(Formatter{MaxLineLength: 256}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = h.Load()
+	if err != nil {
+		t.Fatalf("failed to load hosts file: %v", err)
+	}
+	h.entries[1].Comment = "generated"
+
+	err = h.Save()
+	if err != nil {
+		t.Fatalf("failed to save hosts file: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read hosts file: %v", err)
+	}
+	for i, line := range strings.Split(string(content), "\n") {
+		if len(line) > 256 {
+			t.Fatalf("line %d is %d bytes long", i+1, len(line))
+		}
+	}
+
+	// Every hostname is still mapped to the same IP address
+	err = h.Load()
+	if err != nil {
+		t.Fatalf("failed to load hosts file: %v", err)
+	}
+
+	var loaded []string
+	for _, entry := range h.entries[1:] {
+		if entry.IP != "10.0.0.1" || entry.Comment != "generated" {
+			t.Fatalf("unexpected entry: %v", entry)
+		}
+		loaded = append(loaded, entry.Hostnames...)
+	}
+	if !equalStrings(loaded, hostnames) {
+		t.Errorf("expected %d hostnames, but got %d", len(hostnames), len(loaded))
 	}
 }
 
@@ -137,9 +268,217 @@ func TestSave(t *testing.T) {
 		t.Errorf("failed to load hosts file: %v", err)
 	}
 
-	if len(h.Entries) != 1 {
-		t.Errorf("expected 1 entry, but got %d", len(h.Entries))
+	if len(h.entries) != 1 {
+		t.Errorf("expected 1 entry, but got %d", len(h.entries))
 	}
 }
 
 // TODO: Add more tests for Save method.
+
+func TestSave_Blocks(t *testing.T) {
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "hosts")
+	err := os.WriteFile(path, []byte("10.0.0.1 api.dev.local\n"), 0644)
+	if err != nil {
+		t.Fatalf("failed to write hosts file: %v", err)
+	}
+
+	h, err := New(WithPath(path))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h.entries = []HostEntry{
+		{IP: "10.0.0.1", Hostnames: []string{"api.dev.local"}, Active: true, Block: "dev"},
+		{IP: "127.0.0.1", Hostnames: []string{"localhost"}, Active: true},
+		{IP: "10.0.0.2", Hostnames: []string{"web.dev.local"}, Active: false, Block: "dev"},
+	}
+
+	err = h.Save()
+	if err != nil {
+		t.Fatalf("failed to save hosts file: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read hosts file: %v", err)
+	}
+
+	expected := "127.0.0.1     localhost\n" +
+		"# gohosts:begin dev\n" +
+		"10.0.0.1     api.dev.local\n" +
+		"# 10.0.0.2     web.dev.local\n" +
+		"# gohosts:end dev\n"
+	if string(content) != expected {
+		t.Errorf("\n%s\n\n%s", expected, string(content))
+	}
+
+	if blocks := h.Blocks(); len(blocks) != 1 || blocks[0] != "dev" {
+		t.Errorf("expected blocks [dev], got %v", blocks)
+	}
+}
+
+func TestSave_PreservesEncoding(t *testing.T) {
+	tempPath, err := os.CreateTemp("", "hosts")
+	if err != nil {
+		t.Fatalf("failed to create temporary file: %v", err)
+	}
+	defer os.Remove(tempPath.Name())
+	defer tempPath.Close()
+
+	_, err = tempPath.WriteString("\xEF\xBB\xBF# Windows hosts file\r\n127.0.0.1 localhost\r\n")
+	if err != nil {
+		t.Fatalf("failed to write to temporary file: %v", err)
+	}
+
+	h, err := New(WithPath(tempPath.Name()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Loading twice does not duplicate the additional content
+	for i := 0; i < 2; i++ {
+		err = h.Load()
+		if err != nil {
+			t.Fatalf("failed to load hosts file: %v", err)
+		}
+	}
+
+	err = h.Add("::1", []string{"localhost"}, "")
+	if err != nil {
+		t.Fatalf("failed to add entry: %v", err)
+	}
+
+	err = h.Save()
+	if err != nil {
+		t.Fatalf("failed to save hosts file: %v", err)
+	}
+
+	content, err := os.ReadFile(tempPath.Name())
+	if err != nil {
+		t.Fatalf("failed to read hosts file: %v", err)
+	}
+
+	expected := "\xEF\xBB\xBF# Windows hosts file\r\n127.0.0.1     localhost\r\n::1     localhost\r\n"
+	if string(content) != expected {
+		t.Errorf("\n%q\n\n%q", expected, string(content))
+	}
+}
+
+func TestParse(t *testing.T) {
+	h, err := Parse(strings.NewReader("# header\r\n127.0.0.1 localhost\r\n10.0.0.1 api.local # api\r\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []HostEntry{
+		{IP: "127.0.0.1", Hostnames: []string{"localhost"}, Active: true},
+		{IP: "10.0.0.1", Hostnames: []string{"api.local"}, Comment: "api", Active: true},
+	}
+	if !Equal(h.entries, expected) {
+		t.Errorf("Entries do not match expected entries. \nGot: %v, \nExpected: %v", h.entries, expected)
+	}
+
+	if err := h.Add("10.0.0.2", []string{"web.local"}, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var b strings.Builder
+	n, err := h.WriteTo(&b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "# header\r\n127.0.0.1     localhost\r\n10.0.0.1     api.local     # api\r\n10.0.0.2     web.local\r\n"
+	if b.String() != want {
+		t.Errorf("unexpected output: %q", b.String())
+	}
+	if n != int64(len(want)) {
+		t.Errorf("expected %d bytes written, got %d", len(want), n)
+	}
+
+	// Without a path, the hosts file can only be written with WriteTo or SaveAs
+	if err := h.Save(); err == nil {
+		t.Errorf("Expected an error for saving a hosts file without path")
+	}
+	if err := h.Load(); err == nil {
+		t.Errorf("Expected an error for loading a hosts file without path")
+	}
+}
+
+func TestParseBytes_Options(t *testing.T) {
+	m := NewMemFS()
+	h, err := ParseBytes([]byte("127.0.0.1 localhost\n"), WithFS(m), WithPath("/etc/hosts"), WithCreateIfMissing())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := h.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := m.ReadFile("/etc/hosts")
+	if err != nil || string(data) != "127.0.0.1     localhost\n" {
+		t.Errorf("unexpected content: %q, %v", data, err)
+	}
+}
+
+func TestSaveAs(t *testing.T) {
+	h, err := ParseBytes([]byte("127.0.0.1 localhost\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "hosts")
+	if err := h.SaveAs(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil || string(content) != "127.0.0.1     localhost\n" {
+		t.Errorf("unexpected content: %q, %v", content, err)
+	}
+
+	if err := h.SaveAs(filepath.Join(t.TempDir(), "missing", "hosts")); err == nil {
+		t.Errorf("Expected an error for a missing directory")
+	}
+}
+
+func TestCreateIfMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "etc", "hosts")
+
+	_, err := New(WithPath(path))
+	if err == nil {
+		t.Errorf("Expected an error for a missing hosts file")
+	}
+
+	h, err := New(WithPath(path), WithCreateIfMissing())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := h.Load(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !Equal(h.entries, defaultEntries()) {
+		t.Errorf("expected the default entries, got %v", h.entries)
+	}
+
+	if err := h.Add("10.0.0.1", []string{"api.local"}, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := h.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read hosts file: %v", err)
+	}
+	expected := "127.0.0.1     localhost\n::1     localhost\n10.0.0.1     api.local\n"
+	if string(content) != expected {
+		t.Errorf("unexpected content: %q", content)
+	}
+
+	// No backup is created for the missing file
+	backups, err := getBackupFiles(OSFS{}, path)
+	if err != nil || len(backups) != 0 {
+		t.Errorf("expected no backups, got %v, %v", backups, err)
+	}
+}
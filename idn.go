@@ -0,0 +1,105 @@
+package gohosts
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+)
+
+// idnaProfile converts hostnames between their Unicode and ASCII forms with the UTS 46 rules.
+// The STD3 rules are relaxed as the ASCII form is checked by the hostname validator.
+var idnaProfile = idna.New(idna.MapForLookup(), idna.StrictDomainName(false), idna.Transitional(false))
+
+// ToASCII returns the ASCII form of an internationalized hostname, with its Unicode labels
+// encoded in punycode ("bücher.example" becomes "xn--bcher-kva.example").
+// Hostnames that are already ASCII are returned unchanged.
+func ToASCII(hostname string) (string, error) {
+	if isASCII(hostname) {
+		return hostname, nil
+	}
+
+	ascii, err := idnaProfile.ToASCII(hostname)
+	if err != nil {
+		return "", fmt.Errorf("invalid internationalized hostname: %s: %w", hostname, err)
+	}
+	return ascii, nil
+}
+
+// ToUnicode returns the Unicode form of a hostname, with its punycode labels decoded for display.
+// The hostname is returned unchanged if it has no valid punycode label.
+func ToUnicode(hostname string) string {
+	if !strings.Contains(strings.ToLower(hostname), "xn--") {
+		return hostname
+	}
+
+	unicode, err := idnaProfile.ToUnicode(hostname)
+	if err != nil {
+		return hostname
+	}
+	return unicode
+}
+
+// UnicodeHostnames returns the hostnames of the entry in their Unicode form, for display.
+func (e HostEntry) UnicodeHostnames() []string {
+	hostnames := make([]string, len(e.Hostnames))
+	for i, hostname := range e.Hostnames {
+		hostnames[i] = ToUnicode(hostname)
+	}
+	return hostnames
+}
+
+// isASCII checks if the provided string only contains ASCII characters.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// asciiHostname returns the ASCII form of the hostname used to compare hostnames, or the
+// hostname itself if it cannot be converted.
+func asciiHostname(hostname string) string {
+	ascii, err := ToASCII(hostname)
+	if err != nil {
+		return hostname
+	}
+	return ascii
+}
+
+// hostnameForms returns the distinct forms a hostname can be matched against: as written,
+// in ASCII and in Unicode.
+func hostnameForms(hostname string) []string {
+	forms := []string{hostname}
+	for _, form := range []string{asciiHostname(hostname), ToUnicode(hostname)} {
+		if !contains(forms, form) {
+			forms = append(forms, form)
+		}
+	}
+	return forms
+}
+
+// equalHostnames checks if two hostnames are the same, in Unicode or ASCII form, once normalized.
+func equalHostnames(a, b string) bool {
+	return hostnameKey(a) == hostnameKey(b)
+}
+
+// containsHostnames checks if the hostnames contain all the provided items, compared with equalHostnames.
+func containsHostnames(hostnames []string, items ...string) bool {
+	for _, item := range items {
+		found := false
+		for _, hostname := range hostnames {
+			if equalHostnames(hostname, item) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
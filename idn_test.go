@@ -0,0 +1,156 @@
+package gohosts
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestToASCII(t *testing.T) {
+	tests := []struct {
+		hostname string
+		expected string
+	}{
+		{"bücher.example", "xn--bcher-kva.example"},
+		{"Bücher.example", "xn--bcher-kva.example"},
+		{"例え.テスト", "xn--r8jz45g.xn--zckzah"},
+		{"api.local", "api.local"},
+		{"xn--bcher-kva.example", "xn--bcher-kva.example"},
+	}
+
+	for _, test := range tests {
+		ascii, err := ToASCII(test.hostname)
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", test.hostname, err)
+		}
+		if ascii != test.expected {
+			t.Errorf("ToASCII(%s) = %s, expected %s", test.hostname, ascii, test.expected)
+		}
+	}
+
+	if _, err := ToASCII("bücher‍.example"); err == nil {
+		t.Errorf("Expected an error for a hostname with a disallowed joiner")
+	}
+}
+
+func TestToUnicode(t *testing.T) {
+	tests := []struct {
+		hostname string
+		expected string
+	}{
+		{"xn--bcher-kva.example", "bücher.example"},
+		{"api.xn--bcher-kva.example", "api.bücher.example"},
+		{"api.local", "api.local"},
+		{"xn--99999999.local", "xn--99999999.local"},
+	}
+
+	for _, test := range tests {
+		if unicode := ToUnicode(test.hostname); unicode != test.expected {
+			t.Errorf("ToUnicode(%s) = %s, expected %s", test.hostname, unicode, test.expected)
+		}
+	}
+}
+
+func TestAdd_Unicode(t *testing.T) {
+	h := &HostsFile{}
+
+	err := h.Add("10.0.0.1", []string{"bücher.example", "api.local"}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []HostEntry{
+		{IP: "10.0.0.1", Hostnames: []string{"xn--bcher-kva.example", "api.local"}, Active: true},
+	}
+	if !Equal(h.entries, expected) {
+		t.Errorf("Entries do not match expected entries. \nGot: %v, \nExpected: %v", h.entries, expected)
+	}
+
+	var b strings.Builder
+	if err := h.Format(&b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b.String() != "10.0.0.1     xn--bcher-kva.example api.local\n" {
+		t.Errorf("unexpected output: %q", b.String())
+	}
+
+	unicode := h.entries[0].UnicodeHostnames()
+	if !equalStrings(unicode, []string{"bücher.example", "api.local"}) {
+		t.Errorf("unexpected unicode hostnames: %v", unicode)
+	}
+
+	err = h.Add("10.0.0.1", []string{"bad_ünicode.example"}, "")
+	if err == nil {
+		t.Errorf("Expected an error for an invalid internationalized hostname")
+	}
+}
+
+func TestRemove_Unicode(t *testing.T) {
+	h := &HostsFile{}
+	entries, err := h.parseHosts([]string{
+		"10.0.0.1 xn--bcher-kva.example api.local",
+		"10.0.0.2 straße.example",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h.entries = entries
+
+	// The Unicode form matches the punycode written in the file, and the other way around
+	if err := h.Remove("10.0.0.1", []string{"Bücher.example"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := h.Remove("10.0.0.2", []string{"xn--strae-oqa.example"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []HostEntry{
+		{IP: "10.0.0.1", Hostnames: []string{"api.local"}, Active: true},
+	}
+	if !Equal(h.entries, expected) {
+		t.Errorf("Entries do not match expected entries. \nGot: %v, \nExpected: %v", h.entries, expected)
+	}
+}
+
+func TestFilter_Unicode(t *testing.T) {
+	h := &HostsFile{
+		entries: []HostEntry{
+			{IP: "10.0.0.1", Hostnames: []string{"xn--bcher-kva.example", "api.local"}, Active: true},
+		},
+	}
+
+	glob, err := HostnameGlob("*.example")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		pred Predicate
+	}{
+		{"unicode hostname", Hostname("bücher.example")},
+		{"punycode hostname", Hostname("xn--bcher-kva.example")},
+		{"unicode suffix", HostnameSuffix("bücher.example")},
+		{"glob", glob},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			matched := h.Filter(test.pred)
+			if len(matched) != 1 || !equalStrings(matched[0].Hostnames, []string{"xn--bcher-kva.example"}) {
+				t.Errorf("unexpected matched entries: %v", matched)
+			}
+		})
+	}
+}
+
+func TestAdd_UnicodeConflict(t *testing.T) {
+	h := &HostsFile{conflictPolicy: ConflictFail}
+	h.entries = []HostEntry{
+		{IP: "10.0.0.1", Hostnames: []string{"xn--bcher-kva.example"}, Active: true, Line: 1},
+	}
+
+	err := h.Add("10.0.0.2", []string{"bücher.example"}, "")
+	if err == nil {
+		t.Fatalf("Expected an error for a conflicting internationalized hostname")
+	}
+}
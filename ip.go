@@ -0,0 +1,83 @@
+package gohosts
+
+import (
+	"net/netip"
+)
+
+// IPv4MappedPolicy defines how IPv4-mapped IPv6 addresses, such as "::ffff:10.0.0.1", are handled.
+type IPv4MappedPolicy int
+
+const (
+	// IPv4MappedUnmap stores IPv4-mapped addresses as the IPv4 address they map, the default.
+	IPv4MappedUnmap IPv4MappedPolicy = iota
+	// IPv4MappedKeep keeps IPv4-mapped addresses as IPv6 addresses, distinct from the IPv4
+	// address they map.
+	IPv4MappedKeep
+	// IPv4MappedReject rejects IPv4-mapped addresses as invalid IP addresses.
+	IPv4MappedReject
+)
+
+// WithIPv4MappedPolicy is a HostsOption that sets how IPv4-mapped IPv6 addresses are handled
+// when parsing the hosts file and in every operation taking an IP address.
+func WithIPv4MappedPolicy(p IPv4MappedPolicy) HostsOption {
+	return func(h *HostsFile) {
+		h.ipv4MappedPolicy = p
+	}
+}
+
+// Addr returns the IP address of the entry, including its IPv6 zone if any.
+// The returned address is invalid if the entry IP address cannot be parsed.
+func (e HostEntry) Addr() netip.Addr {
+	addr, err := netip.ParseAddr(e.IP)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addr
+}
+
+// parseAddr parses the IP address, with an optional IPv6 zone such as "fe80::1%eth0",
+// applying the IPv4-mapped policy of the hosts file.
+func (h *HostsFile) parseAddr(ip string) (netip.Addr, bool) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+
+	if addr.Is4In6() {
+		switch h.ipv4MappedPolicy {
+		case IPv4MappedUnmap:
+			addr = addr.Unmap()
+		case IPv4MappedReject:
+			return netip.Addr{}, false
+		}
+	}
+
+	return addr, true
+}
+
+// canonicalIP returns the canonical form of the IP address, the form IP addresses are stored in,
+// so "::0001" becomes "::1". It returns false if the IP address is invalid.
+func (h *HostsFile) canonicalIP(ip string) (string, bool) {
+	addr, ok := h.parseAddr(ip)
+	if !ok {
+		return "", false
+	}
+	return addr.String(), true
+}
+
+// sameIP checks if two IP addresses are the same once parsed, their zones included.
+// IP addresses that cannot be parsed are compared as written.
+func sameIP(a, b string) bool {
+	addrA, errA := netip.ParseAddr(a)
+	addrB, errB := netip.ParseAddr(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return addrA == addrB
+}
+
+// sameFamily checks if two IP addresses are of the same address family. IPv4-mapped
+// addresses that were kept are IPv6 addresses.
+func sameFamily(a, b netip.Addr) bool {
+	return a.Is4() == b.Is4()
+}
@@ -0,0 +1,146 @@
+package gohosts
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestParseHosts_IPAddresses(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   IPv4MappedPolicy
+		line     string
+		expected string
+	}{
+		{"ipv4", IPv4MappedUnmap, "10.0.0.1 api.local", "10.0.0.1"},
+		{"ipv6 canonical form", IPv4MappedUnmap, "0:0:0:0:0:0:0:0001 api.local", "::1"},
+		{"ipv6 uppercase", IPv4MappedUnmap, "FE80::ABCD api.local", "fe80::abcd"},
+		{"ipv6 zone", IPv4MappedUnmap, "fe80::1%eth0 api.local", "fe80::1%eth0"},
+		{"ipv4-mapped unmapped", IPv4MappedUnmap, "::ffff:10.0.0.1 api.local", "10.0.0.1"},
+		{"ipv4-mapped kept", IPv4MappedKeep, "::ffff:10.0.0.1 api.local", "::ffff:10.0.0.1"},
+		{"ipv4-mapped rejected", IPv4MappedReject, "::ffff:10.0.0.1 api.local", ""},
+		{"ipv4 with zone", IPv4MappedUnmap, "10.0.0.1%eth0 api.local", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h := &HostsFile{ipv4MappedPolicy: test.policy}
+			entries, err := h.parseHosts([]string{test.line})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if test.expected == "" {
+				if len(entries) != 0 || len(h.skipped) != 1 || h.skipped[0].reason != skipInvalidIP {
+					t.Errorf("expected the line to be skipped as an invalid IP address, got %v", entries)
+				}
+				return
+			}
+			if len(entries) != 1 || entries[0].IP != test.expected {
+				t.Errorf("expected IP address %s, got %v", test.expected, entries)
+			}
+		})
+	}
+}
+
+func TestAddRemove_CanonicalIP(t *testing.T) {
+	h := &HostsFile{}
+
+	err := h.Add("0:0::1", []string{"localhost"}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = h.Add("FE80::1%eth0", []string{"router.local"}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []HostEntry{
+		{IP: "::1", Hostnames: []string{"localhost"}, Active: true},
+		{IP: "fe80::1%eth0", Hostnames: []string{"router.local"}, Active: true},
+	}
+	if !Equal(h.entries, expected) {
+		t.Errorf("Entries do not match expected entries. \nGot: %v, \nExpected: %v", h.entries, expected)
+	}
+
+	if h.entries[1].Addr() != netip.MustParseAddr("fe80::1%eth0") {
+		t.Errorf("unexpected address: %v", h.entries[1].Addr())
+	}
+
+	// The zone is part of the address
+	err = h.Remove("fe80::1%eth1", []string{"router.local"})
+	if err == nil {
+		t.Errorf("Expected an error for an address with another zone")
+	}
+
+	err = h.Remove("::0001", []string{"localhost"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = h.Remove("fe80:0::1%eth0", []string{"router.local"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(h.entries) != 0 {
+		t.Errorf("expected no entries, got %v", h.entries)
+	}
+}
+
+func TestAdd_IPv4MappedPolicy(t *testing.T) {
+	h := &HostsFile{}
+	if err := h.Add("::ffff:10.0.0.1", []string{"api.local"}, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if h.entries[0].IP != "10.0.0.1" {
+		t.Errorf("expected the address to be unmapped, got %s", h.entries[0].IP)
+	}
+
+	h = &HostsFile{}
+	WithIPv4MappedPolicy(IPv4MappedKeep)(h)
+	if err := h.Add("::ffff:10.0.0.1", []string{"api.local"}, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if h.entries[0].IP != "::ffff:10.0.0.1" {
+		t.Errorf("expected the address to be kept, got %s", h.entries[0].IP)
+	}
+	if err := h.Remove("10.0.0.1", []string{"api.local"}); err == nil {
+		t.Errorf("Expected an error for the IPv4 address of a kept IPv4-mapped address")
+	}
+
+	h = &HostsFile{}
+	WithIPv4MappedPolicy(IPv4MappedReject)(h)
+	if err := h.Add("::ffff:10.0.0.1", []string{"api.local"}, ""); err == nil {
+		t.Errorf("Expected an error for a rejected IPv4-mapped address")
+	}
+}
+
+func TestIPIs(t *testing.T) {
+	entries := []HostEntry{
+		{IP: "::1", Hostnames: []string{"localhost"}, Active: true},
+		{IP: "10.0.0.1", Hostnames: []string{"api.local"}, Active: true},
+		{IP: "fe80::1%eth0", Hostnames: []string{"router.local"}, Active: true},
+	}
+
+	tests := []struct {
+		ip       string
+		expected int
+	}{
+		{"::0001", 0},
+		{"::ffff:10.0.0.1", 1},
+		{"fe80::1%eth0", 2},
+		{"fe80::1", -1},
+		{"invalid", -1},
+	}
+
+	for _, test := range tests {
+		matched := -1
+		for i, entry := range entries {
+			if IPIs(test.ip)(entry) {
+				matched = i
+			}
+		}
+		if matched != test.expected {
+			t.Errorf("IPIs(%s) matched entry %d, expected %d", test.ip, matched, test.expected)
+		}
+	}
+}
@@ -0,0 +1,335 @@
+package gohosts
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+// MaxLineLength is the longest line, in bytes, accepted by the linter. Longer lines may be
+// truncated or ignored by resolvers reading the hosts file into fixed size line buffers.
+const MaxLineLength = 1024
+
+// The IDs of the rules checked by Lint.
+const (
+	RuleInvalidIP            = "invalid-ip"
+	RuleMissingHostname      = "missing-hostname"
+	RuleInvalidHostname      = "invalid-hostname"
+	RuleConflictingIP        = "conflicting-ip"
+	RuleDuplicateLine        = "duplicate-line"
+	RuleShadowedEntry        = "shadowed-entry"
+	RuleMissingLocalhost     = "missing-localhost"
+	RuleMissingIPv6Localhost = "missing-ipv6-localhost"
+	RuleLineTooLong          = "line-too-long"
+)
+
+// Severity is the severity of a lint finding.
+type Severity int
+
+const (
+	// SeverityInfo is used for findings that are worth knowing but harmless.
+	SeverityInfo Severity = iota
+	// SeverityWarning is used for findings that are likely mistakes.
+	SeverityWarning
+	// SeverityError is used for findings that break name resolution.
+	SeverityError
+)
+
+// String returns the name of the severity.
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+}
+
+// Finding is a problem found by Lint.
+type Finding struct {
+	Rule     string
+	Severity Severity
+	// Line is the line number of the offending entry, 0 if the entry was not read from
+	// the file or the finding is about the whole file.
+	Line    int
+	Message string
+	// Fixable reports whether Fix can safely fix the finding. Findings on the entries of a
+	// managed block are left to the profile or fragments writing the block.
+	Fixable bool
+
+	// index is the index of the offending entry, and hostname the offending hostname if any
+	index    int
+	hostname string
+}
+
+// String returns the finding in the "line N: severity: message (rule)" format.
+func (f Finding) String() string {
+	return fmt.Sprintf("line %d: %s: %s (%s)", f.Line, f.Severity, f.Message, f.Rule)
+}
+
+// Lint checks the hosts file for common mistakes, and returns the findings sorted by line.
+func Lint(h *HostsFile) []Finding {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return lint(h)
+}
+
+// lint checks the hosts file for common mistakes, see Lint.
+func lint(h *HostsFile) []Finding {
+	var findings []Finding
+
+	for _, skipped := range h.skipped {
+		switch skipped.reason {
+		case skipInvalidIP:
+			findings = append(findings, Finding{
+				Rule:     RuleInvalidIP,
+				Severity: SeverityError,
+				Line:     skipped.line,
+				Message:  fmt.Sprintf("invalid IP address: %s", strings.Fields(skipped.text)[0]),
+			})
+		case skipMissingHostname:
+			findings = append(findings, Finding{
+				Rule:     RuleMissingHostname,
+				Severity: SeverityError,
+				Line:     skipped.line,
+				Message:  fmt.Sprintf("no hostnames for IP address: %s", skipped.text),
+			})
+		}
+	}
+
+	// The first active mapping of each hostname, per address family
+	type mapping struct {
+		ip   string
+		line int
+	}
+	resolved := make(map[string]mapping)
+	// The first occurrence of each line, by IP address, hostnames and active state
+	seen := make(map[string]int)
+	hasLocalhost, hasIPv6Localhost := false, false
+
+	for i, entry := range h.entries {
+		ip := entry.Addr()
+		if !ip.IsValid() {
+			findings = append(findings, Finding{
+				Rule:     RuleInvalidIP,
+				Severity: SeverityError,
+				Line:     entry.Line,
+				Message:  fmt.Sprintf("invalid IP address: %s", entry.IP),
+			})
+			continue
+		}
+
+		if line := formatEntry(entry); len(line) > MaxLineLength {
+			findings = append(findings, Finding{
+				Rule:     RuleLineTooLong,
+				Severity: SeverityWarning,
+				Line:     entry.Line,
+				Message:  fmt.Sprintf("line is %d bytes long, the limit is %d", len(line), MaxLineLength),
+			})
+		}
+
+		key := lineKey(entry)
+		if first, ok := seen[key]; ok {
+			findings = append(findings, Finding{
+				Rule:     RuleDuplicateLine,
+				Severity: SeverityWarning,
+				Line:     entry.Line,
+				Message:  fmt.Sprintf("duplicate of line %d", h.entries[first].Line),
+				Fixable:  entry.Block == "",
+				index:    i,
+			})
+			continue
+		}
+		seen[key] = i
+
+		if !entry.Active {
+			continue
+		}
+
+		for _, hostname := range entry.Hostnames {
+			if !h.validHostname(hostname) {
+				findings = append(findings, Finding{
+					Rule:     RuleInvalidHostname,
+					Severity: SeverityWarning,
+					Line:     entry.Line,
+					Message:  fmt.Sprintf("invalid hostname: %s", hostname),
+				})
+			}
+
+			if strings.EqualFold(hostname, "localhost") {
+				hasLocalhost = hasLocalhost || ip.Unmap() == netip.AddrFrom4([4]byte{127, 0, 0, 1})
+				hasIPv6Localhost = hasIPv6Localhost || ip == netip.IPv6Loopback()
+			}
+
+			// Lookups are made per address family, so localhost can map to both 127.0.0.1 and ::1
+			family := "6"
+			if ip.Is4() {
+				family = "4"
+			}
+			name := hostnameKey(hostname) + "/" + family
+
+			first, ok := resolved[name]
+			switch {
+			case !ok:
+				resolved[name] = mapping{ip: ip.String(), line: entry.Line}
+			case first.ip != ip.String():
+				findings = append(findings, Finding{
+					Rule:     RuleConflictingIP,
+					Severity: SeverityError,
+					Line:     entry.Line,
+					Message:  fmt.Sprintf("%s is mapped to %s, but line %d already maps it to %s", hostname, entry.IP, first.line, first.ip),
+				})
+			default:
+				findings = append(findings, Finding{
+					Rule:     RuleShadowedEntry,
+					Severity: SeverityWarning,
+					Line:     entry.Line,
+					Message:  fmt.Sprintf("%s is already mapped to %s on line %d", hostname, entry.IP, first.line),
+					Fixable:  entry.Block == "",
+					index:    i,
+					hostname: hostname,
+				})
+			}
+		}
+	}
+
+	if !hasLocalhost {
+		findings = append(findings, Finding{
+			Rule:     RuleMissingLocalhost,
+			Severity: SeverityWarning,
+			Message:  "no active entry maps localhost to 127.0.0.1",
+			Fixable:  true,
+		})
+	}
+	if !hasIPv6Localhost {
+		findings = append(findings, Finding{
+			Rule:     RuleMissingIPv6Localhost,
+			Severity: SeverityInfo,
+			Message:  "no active entry maps localhost to ::1",
+			Fixable:  true,
+		})
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Line < findings[j].Line
+	})
+
+	return findings
+}
+
+// Fix fixes the fixable findings of Lint, and returns the fixed findings. The entries are
+// changed in memory, the hosts file still has to be saved.
+func Fix(h *HostsFile) []Finding {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	defer h.edit("Fix")()
+
+	// The changes are accumulated first so they can all be applied at once without
+	// shifting the entry indexes
+	var prepended []HostEntry
+	var ipv6Localhost bool
+	removed := make(map[int]bool)
+	hostnames := make(map[int][]string)
+
+	var fixed []Finding
+	for _, finding := range lint(h) {
+		if !finding.Fixable {
+			continue
+		}
+
+		switch finding.Rule {
+		case RuleDuplicateLine:
+			removed[finding.index] = true
+		case RuleShadowedEntry:
+			hostnames[finding.index] = append(hostnames[finding.index], finding.hostname)
+		case RuleMissingLocalhost:
+			prepended = append(prepended, HostEntry{IP: "127.0.0.1", Hostnames: []string{"localhost"}, Active: true})
+		case RuleMissingIPv6Localhost:
+			ipv6Localhost = true
+		}
+		fixed = append(fixed, finding)
+	}
+
+	// The IPv6 localhost entry goes right after the IPv4 one outside of any block, or first
+	localhost := HostEntry{IP: "::1", Hostnames: []string{"localhost"}, Active: true}
+	entries := prepended
+	for i, entry := range h.entries {
+		if removed[i] {
+			continue
+		}
+		if shadowed, ok := hostnames[i]; ok {
+			entry.Hostnames = removeShadowed(entry.Hostnames, shadowed)
+			if len(entry.Hostnames) == 0 {
+				continue
+			}
+		}
+		entries = append(entries, entry)
+
+		if ipv6Localhost && entry.Block == "" && isIPv4Localhost(entry) {
+			entries = append(entries, localhost)
+			ipv6Localhost = false
+		}
+	}
+	if ipv6Localhost {
+		entries = append(append(prepended, localhost), entries[len(prepended):]...)
+	}
+	h.entries = entries
+
+	return fixed
+}
+
+// isIPv4Localhost reports whether the entry actively maps localhost to 127.0.0.1.
+func isIPv4Localhost(entry HostEntry) bool {
+	ip, err := netip.ParseAddr(entry.IP)
+	if err != nil || !entry.Active || ip.Unmap() != netip.AddrFrom4([4]byte{127, 0, 0, 1}) {
+		return false
+	}
+	for _, hostname := range entry.Hostnames {
+		if strings.EqualFold(hostname, "localhost") {
+			return true
+		}
+	}
+	return false
+}
+
+// lineKey returns a key identifying entries written as the same line, regardless of the
+// order of the hostnames. Entries of different blocks, or with different tags or expiries, are
+// not the same line, as they are managed differently.
+func lineKey(entry HostEntry) string {
+	hostnames := make([]string, len(entry.Hostnames))
+	for i, hostname := range entry.Hostnames {
+		hostnames[i] = strings.ToLower(hostname)
+	}
+	sort.Strings(hostnames)
+
+	key := fmt.Sprintf("%s %s %t %q %s", entry.IP, strings.Join(hostnames, " "), entry.Active, entry.Block, formatTags(entry.Tags))
+	if !entry.Expires.IsZero() {
+		key += " " + formatExpires(entry.Expires)
+	}
+	return key
+}
+
+// removeShadowed removes one occurrence of each shadowed hostname, starting from the end so
+// the first occurrence of a hostname repeated on the same line is kept.
+func removeShadowed(hostnames, shadowed []string) []string {
+	count := make(map[string]int)
+	for _, hostname := range shadowed {
+		count[hostname]++
+	}
+
+	kept := make([]string, 0, len(hostnames))
+	for i := len(hostnames) - 1; i >= 0; i-- {
+		if count[hostnames[i]] > 0 {
+			count[hostnames[i]]--
+			continue
+		}
+		kept = append([]string{hostnames[i]}, kept...)
+	}
+	return kept
+}
@@ -0,0 +1,136 @@
+package gohosts
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const testLintHosts = `127.0.0.1 localhost
+::1 localhost
+10.0.0.1 api.local
+10.0.0.2 api.local
+10.0.0.1 api.local web.local
+10.0.0.3 cache.local cache.local
+10.0.0.3 invalid_name.local
+999.0.0.1 broken.local
+10.0.0.4
+10.0.0.5 db.local
+10.0.0.5 db.local
+# 10.0.0.6 api.local
+`
+
+func TestLint(t *testing.T) {
+	h, _ := newTestHostsFile(t, testLintHosts)
+
+	expected := []Finding{
+		{Rule: RuleConflictingIP, Severity: SeverityError, Line: 4},
+		{Rule: RuleShadowedEntry, Severity: SeverityWarning, Line: 5, Fixable: true},
+		{Rule: RuleShadowedEntry, Severity: SeverityWarning, Line: 6, Fixable: true},
+		{Rule: RuleInvalidHostname, Severity: SeverityWarning, Line: 7},
+		{Rule: RuleInvalidIP, Severity: SeverityError, Line: 8},
+		{Rule: RuleMissingHostname, Severity: SeverityError, Line: 9},
+		{Rule: RuleDuplicateLine, Severity: SeverityWarning, Line: 11, Fixable: true},
+	}
+
+	findings := Lint(h)
+	if len(findings) != len(expected) {
+		t.Fatalf("expected %d findings, got %d: %v", len(expected), len(findings), findings)
+	}
+	for i, finding := range findings {
+		if finding.Rule != expected[i].Rule || finding.Severity != expected[i].Severity ||
+			finding.Line != expected[i].Line || finding.Fixable != expected[i].Fixable {
+			t.Errorf("expected finding %v, got %v", expected[i], finding)
+		}
+	}
+
+	if findings[0].String() != "line 4: error: api.local is mapped to 10.0.0.2, but line 3 already maps it to 10.0.0.1 (conflicting-ip)" {
+		t.Errorf("unexpected finding message: %s", findings[0])
+	}
+}
+
+func TestLint_MissingLocalhost(t *testing.T) {
+	h, _ := newTestHostsFile(t, "10.0.0.1 api.local\n")
+
+	findings := Lint(h)
+	if len(findings) != 2 {
+		t.Fatalf("expected 2 findings, got %d: %v", len(findings), findings)
+	}
+	if findings[0].Rule != RuleMissingLocalhost || findings[1].Rule != RuleMissingIPv6Localhost {
+		t.Errorf("expected missing localhost findings, got %v", findings)
+	}
+}
+
+func TestLint_LineTooLong(t *testing.T) {
+	var hostnames []string
+	for i := 0; i < MaxLineLength/len("host000.example.com "); i++ {
+		hostnames = append(hostnames, fmt.Sprintf("host%03d.example.com", i))
+	}
+	h, _ := newTestHostsFile(t, "127.0.0.1 localhost\n::1 localhost\n10.0.0.1 "+strings.Join(hostnames, " ")+"\n")
+
+	findings := Lint(h)
+	if len(findings) != 1 || findings[0].Rule != RuleLineTooLong {
+		t.Errorf("expected a line too long finding, got %v", findings)
+	}
+}
+
+func TestFix(t *testing.T) {
+	h, _ := newTestHostsFile(t, testLintHosts)
+
+	fixed := Fix(h)
+	if len(fixed) != 3 {
+		t.Errorf("expected 3 fixed findings, got %d: %v", len(fixed), fixed)
+	}
+
+	expected := []HostEntry{
+		{IP: "127.0.0.1", Hostnames: []string{"localhost"}, Active: true},
+		{IP: "::1", Hostnames: []string{"localhost"}, Active: true},
+		{IP: "10.0.0.1", Hostnames: []string{"api.local"}, Active: true},
+		{IP: "10.0.0.2", Hostnames: []string{"api.local"}, Active: true},
+		{IP: "10.0.0.1", Hostnames: []string{"web.local"}, Active: true},
+		{IP: "10.0.0.3", Hostnames: []string{"cache.local"}, Active: true},
+		{IP: "10.0.0.3", Hostnames: []string{"invalid_name.local"}, Active: true},
+		{IP: "10.0.0.5", Hostnames: []string{"db.local"}, Active: true},
+		{IP: "10.0.0.6", Hostnames: []string{"api.local"}, Active: false},
+	}
+	if !Equal(h.entries, expected) {
+		t.Errorf("Entries do not match expected entries. \nGot: %v, \nExpected: %v", h.entries, expected)
+	}
+
+	for _, finding := range Lint(h) {
+		if finding.Fixable {
+			t.Errorf("expected no fixable findings after fix, got %v", finding)
+		}
+	}
+
+	// Missing localhost entries are added at the top
+	h, _ = newTestHostsFile(t, "10.0.0.1 api.local\n")
+	Fix(h)
+	if len(h.entries) != 3 || h.entries[0].IP != "127.0.0.1" || h.entries[1].IP != "::1" {
+		t.Errorf("expected localhost entries to be added, got %v", h.entries)
+	}
+
+	// The IPv6 localhost entry is added after the IPv4 one
+	h, _ = newTestHostsFile(t, "# header\n10.0.0.1 api.local\n127.0.0.1 localhost\n10.0.0.2 web.local\n")
+	Fix(h)
+	if len(h.entries) != 4 || h.entries[1].IP != "127.0.0.1" || h.entries[2].IP != "::1" {
+		t.Errorf("expected the IPv6 localhost entry after the IPv4 one, got %v", h.entries)
+	}
+}
+
+func TestFix_Blocks(t *testing.T) {
+	content := "127.0.0.1 localhost\n::1 localhost\n10.0.0.1 api.local\n" +
+		"# gohosts:begin profile\n10.0.0.1 api.local\n# gohosts:end profile\n"
+	h, _ := newTestHostsFile(t, content)
+
+	// An entry of a managed block is not a duplicate of an unmanaged line, nor fixed by Fix
+	for _, finding := range Lint(h) {
+		if finding.Rule == RuleDuplicateLine || finding.Fixable {
+			t.Errorf("unexpected finding: %v", finding)
+		}
+	}
+	Fix(h)
+	if len(h.entries) != 4 || h.entries[3].Block != "profile" {
+		t.Errorf("expected the block entry to be kept, got %v", h.entries)
+	}
+}
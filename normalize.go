@@ -0,0 +1,161 @@
+package gohosts
+
+import (
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// NormalizeOptions controls the steps made by Normalize. The steps are applied in the order
+// of the fields.
+type NormalizeOptions struct {
+	// Lowercase converts the hostnames to lowercase.
+	Lowercase bool
+	// MergeByIP merges the entries with the same IP address into the first one. Only entries
+	// of the same block, with the same active state and expiry are merged, their comments
+	// and tags are combined.
+	MergeByIP bool
+	// DedupeHostnames removes the hostnames repeated on the same line.
+	DedupeHostnames bool
+	// CollapseDisabled removes the disabled entries identical to a previous disabled entry.
+	CollapseDisabled bool
+	// Sort sorts the entries by IP address and then by first hostname. Comments stay with
+	// their entries and blocks are kept in place.
+	Sort bool
+}
+
+// Normalize cleans up the entries according to the provided options. The additional content
+// and the comments of the entries are preserved.
+func (h *HostsFile) Normalize(opts NormalizeOptions) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	defer h.edit("Normalize")()
+
+	entries := make([]HostEntry, len(h.entries))
+	copy(entries, h.entries)
+
+	if opts.Lowercase {
+		for i := range entries {
+			hostnames := make([]string, len(entries[i].Hostnames))
+			for j, hostname := range entries[i].Hostnames {
+				hostnames[j] = strings.ToLower(hostname)
+			}
+			entries[i].Hostnames = hostnames
+		}
+	}
+
+	if opts.MergeByIP {
+		entries = mergeByIP(entries)
+	}
+
+	if opts.DedupeHostnames {
+		for i := range entries {
+			entries[i].Hostnames = dedupeStrings(entries[i].Hostnames)
+		}
+	}
+
+	if opts.CollapseDisabled {
+		seen := make(map[string]bool)
+		var collapsed []HostEntry
+		for _, entry := range entries {
+			if !entry.Active {
+				key := entry.Block + "\n" + lineKey(entry)
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+			}
+			collapsed = append(collapsed, entry)
+		}
+		entries = collapsed
+	}
+
+	if opts.Sort {
+		sortEntries(entries)
+	}
+
+	h.entries = entries
+}
+
+// mergeByIP merges the entries with the same IP address, block, active state and expiry
+// into the first one.
+func mergeByIP(entries []HostEntry) []HostEntry {
+	var merged []HostEntry
+	index := make(map[string]int)
+
+	for _, entry := range entries {
+		key := strings.Join([]string{entry.Block, entry.IP, entry.Expires.String(), strconv.FormatBool(entry.Active)}, "\n")
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(merged)
+			entry.Hostnames = append([]string(nil), entry.Hostnames...)
+			entry.Tags = copyTags(entry.Tags)
+			merged = append(merged, entry)
+			continue
+		}
+
+		target := &merged[i]
+		target.Hostnames = append(target.Hostnames, entry.Hostnames...)
+		if entry.Comment != "" && !strings.Contains(target.Comment, entry.Comment) {
+			if target.Comment == "" {
+				target.Comment = entry.Comment
+			} else {
+				target.Comment += "; " + entry.Comment
+			}
+		}
+		for key, value := range entry.Tags {
+			if target.Tags == nil {
+				target.Tags = make(map[string]string)
+			}
+			// The tags of the first entry take precedence
+			if _, ok := target.Tags[key]; !ok {
+				target.Tags[key] = value
+			}
+		}
+	}
+
+	return merged
+}
+
+// dedupeStrings removes the repeated strings from the slice, keeping the first occurrence.
+func dedupeStrings(slice []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, s := range slice {
+		if !seen[s] {
+			seen[s] = true
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// sortEntries sorts the entries of each block by IP address and then by first hostname.
+// Each block keeps the positions its entries had, so blocks do not move relative to each other.
+func sortEntries(entries []HostEntry) {
+	positions := make(map[string][]int)
+	for i, entry := range entries {
+		positions[entry.Block] = append(positions[entry.Block], i)
+	}
+
+	for _, indexes := range positions {
+		group := make([]HostEntry, len(indexes))
+		for i, index := range indexes {
+			group[i] = entries[index]
+		}
+
+		sort.SliceStable(group, func(i, j int) bool {
+			a, errA := netip.ParseAddr(group[i].IP)
+			b, errB := netip.ParseAddr(group[j].IP)
+			if errA == nil && errB == nil && a != b {
+				return a.Less(b)
+			}
+			return strings.Join(group[i].Hostnames, " ") < strings.Join(group[j].Hostnames, " ")
+		})
+
+		for i, index := range indexes {
+			entries[index] = group[i]
+		}
+	}
+}
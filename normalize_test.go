@@ -0,0 +1,66 @@
+package gohosts
+
+import (
+	"testing"
+)
+
+// testNormalizeEntries are set as they are, the parser would lowercase the hostnames.
+var testNormalizeEntries = []HostEntry{
+	{IP: "127.0.0.1", Hostnames: []string{"localhost"}, Active: true},
+	{IP: "10.0.0.2", Hostnames: []string{"Web.local", "web.local"}, Comment: "web", Tags: map[string]string{"owner": "web"}, Active: true},
+	{IP: "10.0.0.1", Hostnames: []string{"api.local"}, Comment: "api", Tags: map[string]string{"owner": "api"}, Active: true},
+	{IP: "10.0.0.2", Hostnames: []string{"static.local", "WEB.local"}, Comment: "static", Tags: map[string]string{"owner": "static", "ticket": "OPS-1"}, Active: true},
+	{IP: "10.0.0.9", Hostnames: []string{"old.local"}, Active: false},
+	{IP: "10.0.0.9", Hostnames: []string{"old.local"}, Active: false},
+	{IP: "10.0.0.2", Hostnames: []string{"dev.local"}, Active: true, Block: "dev"},
+	{IP: "10.0.0.1", Hostnames: []string{"dev.local"}, Active: true, Block: "dev"},
+}
+
+func TestNormalize(t *testing.T) {
+	h, _ := newTestHostsFile(t, "# header comment\n")
+	h.SetEntries(testNormalizeEntries)
+
+	h.Normalize(NormalizeOptions{
+		Lowercase:        true,
+		MergeByIP:        true,
+		DedupeHostnames:  true,
+		CollapseDisabled: true,
+		Sort:             true,
+	})
+
+	expected := []HostEntry{
+		{IP: "10.0.0.1", Hostnames: []string{"api.local"}, Comment: "api", Tags: map[string]string{"owner": "api"}, Active: true},
+		// The tags of the first merged entry take precedence
+		{IP: "10.0.0.2", Hostnames: []string{"web.local", "static.local"}, Comment: "web; static", Tags: map[string]string{"owner": "web", "ticket": "OPS-1"}, Active: true},
+		{IP: "10.0.0.9", Hostnames: []string{"old.local"}, Active: false},
+		{IP: "127.0.0.1", Hostnames: []string{"localhost"}, Active: true},
+		{IP: "10.0.0.1", Hostnames: []string{"dev.local"}, Active: true, Block: "dev"},
+		{IP: "10.0.0.2", Hostnames: []string{"dev.local"}, Active: true, Block: "dev"},
+	}
+
+	if !Equal(h.entries, expected) {
+		t.Errorf("Entries do not match expected entries. \nGot: %v, \nExpected: %v", h.entries, expected)
+	}
+
+	if h.additionalContent != "# header comment\n" {
+		t.Errorf("expected the additional content to be preserved, got %q", h.additionalContent)
+	}
+}
+
+func TestNormalize_Options(t *testing.T) {
+	h, _ := newTestHostsFile(t, "# header comment\n")
+	h.SetEntries(testNormalizeEntries)
+
+	// Without lowercasing, hostnames differing in case are kept
+	h.Normalize(NormalizeOptions{DedupeHostnames: true})
+	if len(h.entries[1].Hostnames) != 2 {
+		t.Errorf("expected 2 hostnames, got %v", h.entries[1].Hostnames)
+	}
+
+	// Without options, nothing changes
+	h.SetEntries(testNormalizeEntries)
+	h.Normalize(NormalizeOptions{})
+	if !Equal(h.entries, testNormalizeEntries) {
+		t.Errorf("expected the entries to be unchanged, got %v", h.entries)
+	}
+}
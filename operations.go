@@ -2,23 +2,38 @@ package gohosts
 
 import (
 	"fmt"
-	"net"
 )
 
-// Add appends a new host entry to the hosts file.
-func (h *HostsFile) Add(ip string, hostname []string, comment string) error {
-	if net.ParseIP(ip) == nil {
-		return fmt.Errorf("invalid IP address: %s", ip)
+// Add appends a new host entry to the hosts file. Options such as WithExpiry and WithTags
+// can be provided to configure the entry further. Hostnames already mapped to a different
+// IP address are handled according to the conflict policy of the hosts file.
+// Hostnames are checked by the hostname validator and stored lowercase without trailing dot,
+// internationalized hostnames are accepted and stored in their punycode form.
+// The IP address is stored in its canonical form, IPv6 addresses may have a zone.
+func (h *HostsFile) Add(ip string, hostname []string, comment string, opts ...EntryOption) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	defer h.edit("Add")()
+
+	return h.add(ip, hostname, comment, opts...)
+}
+
+// add appends a new host entry to the hosts file, see Add.
+func (h *HostsFile) add(ip string, hostname []string, comment string, opts ...EntryOption) error {
+	canonical, ok := h.canonicalIP(ip)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrInvalidIP, ip)
 	}
+	ip = canonical
 
 	if len(hostname) == 0 {
-		return fmt.Errorf("no hostnames provided")
+		return ErrNoHostnames
 	}
 
-	for _, hostname := range hostname {
-		if !isValidHostname(hostname) {
-			return fmt.Errorf("invalid hostname: %s", hostname)
-		}
+	// Hostnames are stored normalized, internationalized hostnames in their punycode form
+	hostname, err := h.normalizeHostnames(hostname)
+	if err != nil {
+		return err
 	}
 
 	entry := HostEntry{
@@ -27,14 +42,39 @@ func (h *HostsFile) Add(ip string, hostname []string, comment string) error {
 		Comment:   comment,
 		Active:    true,
 	}
-	h.Entries = append(h.Entries, entry)
+	for _, opt := range opts {
+		opt(&entry)
+	}
+	if err := validateTags(entry.Tags); err != nil {
+		return err
+	}
+	if err := validateComment(entry.Comment); err != nil {
+		return err
+	}
+
+	hostnames, entries, err := h.resolveConflicts(ip, hostname)
+	if err != nil {
+		return err
+	}
+	h.entries = entries
+
+	// All the hostnames may have been dropped by the conflict policy
+	if len(hostnames) == 0 {
+		return nil
+	}
+	entry.Hostnames = hostnames
+	h.entries = append(h.entries, entry)
 	return nil
 }
 
 // AddBatch appends multiple host entries to the hosts file.
 func (h *HostsFile) AddBatch(entries ...HostEntry) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	defer h.edit("AddBatch")()
+
 	for _, entry := range entries {
-		err := h.Add(entry.IP, entry.Hostnames, entry.Comment)
+		err := h.add(entry.IP, entry.Hostnames, entry.Comment, WithExpiry(entry.Expires), WithTags(entry.Tags))
 		if err != nil {
 			return err
 		}
@@ -43,44 +83,64 @@ func (h *HostsFile) AddBatch(entries ...HostEntry) error {
 	return nil
 }
 
-// Remove deletes a host entry from the hosts file.
+// Remove deletes a host entry from the hosts file. Hostnames are matched in their Unicode
+// or punycode form, ignoring case and trailing dots.
 func (h *HostsFile) Remove(ip string, hostname []string) error {
-	if net.ParseIP(ip) == nil {
-		return fmt.Errorf("invalid IP address: %s", ip)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	defer h.edit("Remove")()
+
+	return h.remove(ip, hostname)
+}
+
+// remove deletes a host entry from the hosts file, see Remove.
+func (h *HostsFile) remove(ip string, hostname []string) error {
+	canonical, ok := h.canonicalIP(ip)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrInvalidIP, ip)
 	}
+	ip = canonical
 
 	if len(hostname) == 0 {
-		return fmt.Errorf("no hostnames provided")
+		return ErrNoHostnames
 	}
 
-	for _, hostname := range hostname {
-		if !isValidHostname(hostname) {
-			return fmt.Errorf("invalid hostname: %s", hostname)
-		}
+	hostname, err := h.normalizeHostnames(hostname)
+	if err != nil {
+		return err
 	}
 
-	for i, entry := range h.Entries {
-		if entry.IP == ip && contains(entry.Hostnames, hostname...) {
-			if len(entry.Hostnames) == 1 || len(entry.Hostnames) == len(hostname) {
-				// Remove the entire entry if it's the only hostname
-				h.Entries = append(h.Entries[:i], h.Entries[i+1:]...)
-			} else {
-				// Remove the specific hostname from the entry
-				entry.Hostnames = removeStrings(entry.Hostnames, hostname...)
-				h.Entries[i] = entry
+	for i, entry := range h.entries {
+		if sameIP(entry.IP, ip) && containsHostnames(entry.Hostnames, hostname...) {
+			// The entries may be shared with a snapshot, they are replaced rather than changed in place
+			entries := make([]HostEntry, 0, len(h.entries))
+			entries = append(entries, h.entries[:i]...)
+			// Remove the specific hostnames from the entry, the provided hostnames may name the
+			// same hostname several times, such as in different cases
+			for _, name := range hostname {
+				entry.Hostnames = removeHostname(entry.Hostnames, name)
 			}
+			// The entire entry is removed when no hostname is left
+			if len(entry.Hostnames) > 0 {
+				entries = append(entries, entry)
+			}
+			h.entries = append(entries, h.entries[i+1:]...)
 
 			return nil
 		}
 	}
-	return fmt.Errorf("host entry not found: IP=%s, Hostname=%s", ip, hostname)
+	return fmt.Errorf("%w: IP=%s, Hostname=%s", ErrEntryNotFound, ip, hostname)
 }
 
 // RemoveBatch deletes multiple host entries from the hosts file.
 func (h *HostsFile) RemoveBatch(entries ...HostEntry) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	defer h.edit("RemoveBatch")()
+
 	for _, removedEntry := range entries {
 		// TODO: maybe add a comment match as well, I don't know seem useless, who knows
-		err := h.Remove(removedEntry.IP, removedEntry.Hostnames)
+		err := h.remove(removedEntry.IP, removedEntry.Hostnames)
 		if err != nil {
 			return err
 		}
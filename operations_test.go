@@ -78,8 +78,8 @@ func TestAddBatch(t *testing.T) {
 		{IP: "192.168.0.1", Hostnames: []string{"router"}, Comment: "Entry 2", Active: true},
 	}
 
-	if !compareEntries(h.Entries, expectedEntries) {
-		t.Errorf("Entries do not match expected entries. \nGot: %v, \nExpected: %v", h.Entries, expectedEntries)
+	if !Equal(h.entries, expectedEntries) {
+		t.Errorf("Entries do not match expected entries. \nGot: %v, \nExpected: %v", h.entries, expectedEntries)
 	}
 }
 
@@ -92,7 +92,7 @@ func TestRemove(t *testing.T) {
 	defer os.Remove(tempFile.Name())
 
 	h := &HostsFile{
-		Entries: []HostEntry{
+		entries: []HostEntry{
 			{IP: "127.0.0.1", Hostnames: []string{"localhost", "local"}, Comment: "Test entry", Active: false},
 			{IP: "192.168.0.1", Hostnames: []string{"router"}, Comment: "Router entry"},
 		},
@@ -139,8 +139,8 @@ func TestRemove(t *testing.T) {
 		{IP: "127.0.0.1", Hostnames: []string{"localhost"}, Comment: "Test entry", Active: false},
 	}
 
-	if !compareEntries(h.Entries, expectedEntries) {
-		t.Errorf("Entries do not match expected entries. \nGot: %v, \nExpected: %v", h.Entries, expectedEntries)
+	if !Equal(h.entries, expectedEntries) {
+		t.Errorf("Entries do not match expected entries. \nGot: %v, \nExpected: %v", h.entries, expectedEntries)
 	}
 }
 
@@ -153,7 +153,7 @@ func TestRemoveBatch(t *testing.T) {
 	defer os.Remove(tempFile.Name())
 
 	h := &HostsFile{
-		Entries: []HostEntry{
+		entries: []HostEntry{
 			{IP: "127.0.0.1", Hostnames: []string{"localhost", "local"}, Comment: "Test entry"},
 			{IP: "192.168.0.1", Hostnames: []string{"router"}, Comment: "Router entry"},
 		},
@@ -183,7 +183,7 @@ func TestRemoveBatch(t *testing.T) {
 		{IP: "127.0.0.1", Hostnames: []string{"localhost"}, Comment: "Test entry"},
 	}
 
-	if !compareEntries(h.Entries, expectedEntries) {
-		t.Errorf("Entries do not match expected entries. \nGot: %v, \nExpected: %v", h.Entries, expectedEntries)
+	if !Equal(h.entries, expectedEntries) {
+		t.Errorf("Entries do not match expected entries. \nGot: %v, \nExpected: %v", h.entries, expectedEntries)
 	}
 }
@@ -2,21 +2,74 @@ package gohosts
 
 import (
 	"bufio"
-	"net"
-	"os"
+	"bytes"
 	"strings"
+	"time"
+	"unicode"
 )
 
+// utf8BOM is the UTF-8 byte order mark some editors write at the start of the file.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// Encoding describes how the hosts file is encoded on disk.
+type Encoding struct {
+	// BOM reports whether the file starts with a UTF-8 byte order mark.
+	BOM bool
+	// LineEnding is the line ending used by the file, "\n" or "\r\n".
+	LineEnding string
+}
+
+// Encoding returns the encoding detected when the hosts file was loaded. Save writes the file
+// back with the same encoding, unless the formatter sets a line ending.
+func (h *HostsFile) Encoding() Encoding {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	encoding := h.encoding
+	if encoding.LineEnding == "" {
+		encoding.LineEnding = "\n"
+	}
+	return encoding
+}
+
+// detectEncoding returns the encoding of the provided file content, along with the content
+// without its byte order mark.
+func detectEncoding(content []byte) (Encoding, []byte) {
+	encoding := Encoding{LineEnding: "\n"}
+
+	if bytes.HasPrefix(content, utf8BOM) {
+		encoding.BOM = true
+		content = content[len(utf8BOM):]
+	}
+
+	// The first line ending is the one used by the file
+	if i := bytes.IndexByte(content, '\n'); i > 0 && content[i-1] == '\r' {
+		encoding.LineEnding = "\r\n"
+	}
+
+	return encoding, content
+}
+
 // readHosts reads the hosts file and returns its content as a slice of strings.
+// The encoding of the file is detected and stored, lines are returned without their line ending.
 func (h *HostsFile) readHosts() ([]string, error) {
-	file, err := os.Open(h.path)
+	content, err := readFile(h.filesystem(), h.path)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
+
+	return h.splitLines(content)
+}
+
+// splitLines detects and stores the encoding of the hosts file content, and returns its lines
+// without their line ending.
+func (h *HostsFile) splitLines(content []byte) ([]string, error) {
+	h.encoding, content = detectEncoding(content)
 
 	var lines []string
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	// Lines can be as long as the whole file, generated files may have huge hostname lists
+	scanner.Buffer(nil, max(len(content)+1, bufio.MaxScanTokenSize))
 	for scanner.Scan() {
 		lines = append(lines, scanner.Text())
 	}
@@ -28,13 +81,35 @@ func (h *HostsFile) readHosts() ([]string, error) {
 	return lines, nil
 }
 
+// skipReason is the reason a line of the hosts file was skipped by the parser.
+type skipReason int
+
+const (
+	skipMissingHostname skipReason = iota
+	skipInvalidIP
+)
+
+// skippedLine is a line of the hosts file that is neither a comment nor a valid entry,
+// text holds the line without its comment.
+type skippedLine struct {
+	line   int
+	text   string
+	reason skipReason
+}
+
 // parseHosts parses the provided lines of the hosts file and returns a slice of HostEntry structs.
 // Each HostEntry struct represents a single entry in the hosts file, containing the IP address,
-// hostnames, comment, and active status.
+// hostnames, comment, active status, line number and the managed block it belongs to.
 func (h *HostsFile) parseHosts(lines []string) ([]HostEntry, error) {
 	var entries []HostEntry
-
-	for _, line := range lines {
+	var block string
+	// The line of the last entry, its hostnames and their offset in the line, to join the lines
+	// split by the formatter
+	lastLine, lastOffset := 0, 0
+	var lastHostnames []string
+	h.skipped = nil
+
+	for i, line := range lines {
 		originalLine := line // Keep the original line for additional content purposes
 		line = strings.TrimSpace(line)
 
@@ -47,51 +122,105 @@ func (h *HostsFile) parseHosts(lines []string) ([]HostEntry, error) {
 		isActive := true
 		if line[0] == '#' {
 			trimmedLine := strings.TrimSpace(line[1:])
+			// Block markers are not kept as additional content, they are written back
+			// around the entries of the block on save
+			if name, begin, ok := parseBlockMarker(trimmedLine); ok {
+				if begin {
+					block = name
+				} else if block == name {
+					block = ""
+				}
+				continue
+			}
 			// If after trimming it looks like a valid entry (has space), and the first field
 			// is a valid IP, then it's an inactive host entry
-			if strings.Contains(trimmedLine, " ") && net.ParseIP(strings.Fields(trimmedLine)[0]) != nil {
+			if _, ok := h.parseAddr(firstField(trimmedLine)); ok && strings.Contains(trimmedLine, " ") {
 				line = trimmedLine
 				isActive = false
 			} else {
 				// Otherwise, it's just a comment line, add it to the additional content and skip
-				h.AditionalContent += originalLine + "\n"
+				h.additionalContent += originalLine + "\n"
 				continue
 			}
 		}
 
 		var hostnames []string
 		var comment string
+		var tags map[string]string
+		var expires time.Time
 
 		commentIndex := strings.Index(line, "#")
 		// If there's a comment, separate it from the line
 		if commentIndex != -1 {
-			comment = strings.TrimSpace(line[commentIndex+1:])
+			comment, expires = extractExpiry(strings.TrimSpace(line[commentIndex+1:]))
+			comment, tags = extractTags(comment)
 			line = strings.TrimSpace(line[:commentIndex])
 		}
 
 		parts := strings.Fields(line)
 		// If there's no hostname, skip
 		if len(parts) < 2 {
+			reason := skipMissingHostname
+			if _, ok := h.parseAddr(firstField(line)); !ok {
+				reason = skipInvalidIP
+			}
+			h.skipped = append(h.skipped, skippedLine{line: i + 1, text: line, reason: reason})
 			continue
 		}
 
-		// The first part should be the IP address
-		ip := net.ParseIP(parts[0])
-		if ip == nil {
+		// The first part should be the IP address, stored in its canonical form
+		ip, ok := h.canonicalIP(parts[0])
+		if !ok {
+			h.skipped = append(h.skipped, skippedLine{line: i + 1, text: line, reason: skipInvalidIP})
 			continue
 		}
 
-		// Finally, the rest of the parts are the hostnames
-		hostnames = parts[1:]
+		// Finally, the rest of the parts are the hostnames, normalized. A disabled line may be
+		// prose that only looks like an entry, it is kept as written.
+		for _, hostname := range parts[1:] {
+			if isActive {
+				hostname = normalizeHostname(hostname)
+			}
+			hostnames = append(hostnames, hostname)
+		}
 
 		entry := HostEntry{
-			IP:        ip.String(),
+			Line:      i + 1,
+			IP:        ip,
 			Hostnames: hostnames,
 			Comment:   comment,
+			Tags:      tags,
 			Active:    isActive,
+			Expires:   expires,
+			Block:     block,
+		}
+
+		// A wrapping formatter writes an entry over consecutive lines, they are joined back when
+		// the previous line was full, as lines written separately are not
+		offset := hostnamesOffset(originalLine, parts[0])
+		if last := len(entries) - 1; h.formatter.wraps() && last >= 0 && lastLine == i && continues(entries[last], entry) &&
+			h.formatter.full(lastOffset, lastHostnames, entryComment(entry), entry.Hostnames[0]) {
+			entries[last].Hostnames = append(entries[last].Hostnames, entry.Hostnames...)
+		} else {
+			entries = append(entries, entry)
 		}
-		entries = append(entries, entry)
+		lastLine, lastOffset, lastHostnames = i+1, offset, hostnames
 	}
 
 	return entries, nil
 }
+
+// hostnamesOffset returns the offset of the hostnames in the line, right after the IP address.
+func hostnamesOffset(line, ip string) int {
+	offset := strings.Index(line, ip) + len(ip)
+	return offset + len(line[offset:]) - len(strings.TrimLeftFunc(line[offset:], unicode.IsSpace))
+}
+
+// firstField returns the first whitespace separated field of the line, empty if there is none.
+func firstField(line string) string {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
@@ -83,7 +83,7 @@ func TestRaedHosts_InvalidPath(t *testing.T) {
 	}
 }
 
-func TestReadHosts_ScanError(t *testing.T) {
+func TestReadHosts_LongLine(t *testing.T) {
 	tempFile, err := os.CreateTemp("", "empty")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -101,9 +101,13 @@ func TestReadHosts_ScanError(t *testing.T) {
 	}
 
 	h := &HostsFile{path: tempFile.Name()}
-	_, err = h.readHosts()
-	if err == nil {
-		t.Fatalf("expected error, got nil")
+	lines, err := h.readHosts()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(lines) != 1 || len(lines[0]) != len(content) {
+		t.Fatalf("expected 1 line of %d bytes, got %d lines", len(content), len(lines))
 	}
 }
 
@@ -200,8 +204,8 @@ func TestParseHosts(t *testing.T) {
 	// # Basic Functionality
 	// `
 
-	if h.AditionalContent != expectedAdditionalContent {
-		t.Errorf("\n%s\n\n%s", expectedAdditionalContent, h.AditionalContent)
+	if h.additionalContent != expectedAdditionalContent {
+		t.Errorf("\n%s\n\n%s", expectedAdditionalContent, h.additionalContent)
 	}
 }
 
@@ -276,8 +280,97 @@ func TestParseHosts_OneLine(t *testing.T) {
 		t.Errorf("expected active entry, got inactive")
 	}
 
-	if h.AditionalContent != "" {
-		t.Errorf("expected empty additional content, got %s", h.AditionalContent)
+	if h.additionalContent != "" {
+		t.Errorf("expected empty additional content, got %s", h.additionalContent)
+	}
+
+}
+
+func TestParseHosts_Blocks(t *testing.T) {
+	h := &HostsFile{}
+
+	lines := []string{
+		"127.0.0.1 localhost",
+		"# gohosts:begin dev",
+		"10.0.0.1 api.dev.local",
+		"# 10.0.0.2 web.dev.local",
+		"# gohosts:end dev",
+		"10.0.0.3 outside.local",
+	}
+
+	entries, err := h.parseHosts(lines)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []HostEntry{
+		{Line: 1, IP: "127.0.0.1", Hostnames: []string{"localhost"}, Active: true},
+		{Line: 3, IP: "10.0.0.1", Hostnames: []string{"api.dev.local"}, Active: true, Block: "dev"},
+		{Line: 4, IP: "10.0.0.2", Hostnames: []string{"web.dev.local"}, Active: false, Block: "dev"},
+		{Line: 6, IP: "10.0.0.3", Hostnames: []string{"outside.local"}, Active: true},
+	}
+
+	if !Equal(entries, expected) {
+		t.Errorf("Entries do not match expected entries. \nGot: %v, \nExpected: %v", entries, expected)
+	}
+	for i := range expected {
+		if entries[i].Line != expected[i].Line {
+			t.Errorf("expected line %d, got %d", expected[i].Line, entries[i].Line)
+		}
+	}
+
+	if h.additionalContent != "" {
+		t.Errorf("expected empty additional content, got %s", h.additionalContent)
 	}
+}
 
+func TestReadHosts_Encoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected Encoding
+	}{
+		{name: "LF", content: "127.0.0.1 localhost\n::1 localhost\n", expected: Encoding{LineEnding: "\n"}},
+		{name: "CRLF", content: "127.0.0.1 localhost\r\n::1 localhost\r\n", expected: Encoding{LineEnding: "\r\n"}},
+		{name: "BOM", content: "\xEF\xBB\xBF127.0.0.1 localhost\n::1 localhost\n", expected: Encoding{BOM: true, LineEnding: "\n"}},
+		{name: "BOM and CRLF", content: "\xEF\xBB\xBF127.0.0.1 localhost\r\n::1 localhost", expected: Encoding{BOM: true, LineEnding: "\r\n"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tempFile, err := os.CreateTemp("", "hosts")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer os.Remove(tempFile.Name())
+
+			_, err = tempFile.WriteString(test.content)
+			if err != nil {
+				t.Fatalf("failed to write to temporary file: %v", err)
+			}
+
+			h := &HostsFile{path: tempFile.Name()}
+			lines, err := h.readHosts()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if h.Encoding() != test.expected {
+				t.Errorf("expected encoding %+v, got %+v", test.expected, h.Encoding())
+			}
+
+			entries, err := h.parseHosts(lines)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			expected := []HostEntry{
+				{IP: "127.0.0.1", Hostnames: []string{"localhost"}, Active: true},
+				{IP: "::1", Hostnames: []string{"localhost"}, Active: true},
+			}
+			if !Equal(entries, expected) {
+				t.Errorf("Entries do not match expected entries. \nGot: %v, \nExpected: %v", entries, expected)
+			}
+		})
+	}
 }
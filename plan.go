@@ -0,0 +1,302 @@
+package gohosts
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ChangeAction is the kind of change made to a host entry.
+type ChangeAction int
+
+const (
+	// ActionAdd adds a new host entry.
+	ActionAdd ChangeAction = iota
+	// ActionRemove removes an existing host entry.
+	ActionRemove
+	// ActionUpdate changes the hostnames, the comment or the expiry of an existing host entry.
+	ActionUpdate
+	// ActionEnable uncomments an existing host entry.
+	ActionEnable
+	// ActionDisable comments out an existing host entry.
+	ActionDisable
+)
+
+// MarshalText implements the encoding.TextMarshaler interface, the action is encoded by name.
+func (a ChangeAction) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (a *ChangeAction) UnmarshalText(text []byte) error {
+	for action := ActionAdd; action <= ActionDisable; action++ {
+		if action.String() == string(text) {
+			*a = action
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid change action: %s", text)
+}
+
+// String returns the name of the action.
+func (a ChangeAction) String() string {
+	switch a {
+	case ActionAdd:
+		return "add"
+	case ActionRemove:
+		return "remove"
+	case ActionUpdate:
+		return "update"
+	case ActionEnable:
+		return "enable"
+	case ActionDisable:
+		return "disable"
+	default:
+		return fmt.Sprintf("ChangeAction(%d)", int(a))
+	}
+}
+
+// symbol returns the prefix used when rendering a change of this action.
+func (a ChangeAction) symbol() string {
+	switch a {
+	case ActionAdd:
+		return "+"
+	case ActionRemove:
+		return "-"
+	default:
+		return "~"
+	}
+}
+
+// Change is a single change to a host entry. Before is the zero value for an add,
+// and After is the zero value for a remove.
+type Change struct {
+	Action ChangeAction `json:"action" yaml:"action"`
+	Before HostEntry    `json:"before,omitzero" yaml:"before,omitempty"`
+	After  HostEntry    `json:"after,omitzero" yaml:"after,omitempty"`
+}
+
+// String returns a human readable description of the change.
+func (c Change) String() string {
+	switch c.Action {
+	case ActionAdd:
+		return fmt.Sprintf("%s %s", c.Action.symbol(), formatEntry(c.After))
+	case ActionRemove:
+		return fmt.Sprintf("%s %s", c.Action.symbol(), formatEntry(c.Before))
+	default:
+		return fmt.Sprintf("%s %s -> %s", c.Action.symbol(), formatEntry(c.Before), formatEntry(c.After))
+	}
+}
+
+// Scope selects the entries of the hosts file managed by a plan.
+// At least one of Block or Tag must be set, if both are set an entry must match both.
+type Scope struct {
+	// Block is the name of the managed block holding the entries.
+	Block string
+	// Tag is either a key=value metadata tag the entries must have, or a word that
+	// must appear in the comment of the entries.
+	Tag string
+}
+
+// String returns a human readable description of the scope.
+func (s Scope) String() string {
+	var parts []string
+	if s.Block != "" {
+		parts = append(parts, fmt.Sprintf("block %q", s.Block))
+	}
+	if s.Tag != "" {
+		parts = append(parts, fmt.Sprintf("tag %q", s.Tag))
+	}
+	return strings.Join(parts, " and ")
+}
+
+// contains checks if the entry is managed by the scope.
+func (s Scope) contains(entry HostEntry) bool {
+	if s.Block != "" && entry.Block != s.Block {
+		return false
+	}
+	if s.Tag == "" {
+		return true
+	}
+	if key, value, ok := parseTag(s.Tag); ok {
+		return entry.HasTag(key, value)
+	}
+	return contains(strings.Fields(entry.Comment), s.Tag)
+}
+
+// claim makes the entry part of the scope by moving it to the scope block and
+// adding the scope tag to it.
+func (s Scope) claim(entry HostEntry) HostEntry {
+	if s.Block != "" {
+		entry.Block = s.Block
+	}
+	if s.Tag == "" || s.contains(entry) {
+		return entry
+	}
+
+	if key, value, ok := parseTag(s.Tag); ok {
+		entry.Tags = copyTags(entry.Tags)
+		if entry.Tags == nil {
+			entry.Tags = make(map[string]string)
+		}
+		entry.Tags[key] = value
+	} else {
+		entry.Comment = strings.TrimSpace(entry.Comment + " " + s.Tag)
+	}
+	return entry
+}
+
+// Plan is the set of changes needed to bring the entries in a scope to a desired state.
+type Plan struct {
+	Scope   Scope
+	Changes []Change
+}
+
+// Empty reports whether the plan has no changes.
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// String renders the plan for humans, with a summary line followed by one line per change.
+func (p *Plan) String() string {
+	counts := make(map[ChangeAction]int)
+	for _, change := range p.Changes {
+		counts[change.Action]++
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Plan for %s: %d to add, %d to update, %d to remove, %d to enable, %d to disable\n",
+		p.Scope, counts[ActionAdd], counts[ActionUpdate], counts[ActionRemove], counts[ActionEnable], counts[ActionDisable])
+	for _, change := range p.Changes {
+		b.WriteString(change.String() + "\n")
+	}
+
+	return b.String()
+}
+
+// Plan computes the changes needed to make the entries in the scope match the desired entries.
+// Entries are matched by IP address, each IP address may appear only once in the desired entries.
+// The Active field of the desired entries is used as is, new entries are added to the scope block
+// and tagged with the scope tag.
+func (h *HostsFile) Plan(scope Scope, desired ...HostEntry) (*Plan, error) {
+	if scope.Block == "" && scope.Tag == "" {
+		return nil, fmt.Errorf("scope must specify a block or a tag")
+	}
+
+	wanted := make(map[string]HostEntry)
+	var order []string
+	for _, entry := range desired {
+		// The desired entries are checked as imported entries, once claimed by the scope
+		entry = scope.claim(entry)
+		if err := h.validateImportedEntry(&entry); err != nil {
+			return nil, err
+		}
+
+		entry.Line = 0
+		if _, ok := wanted[entry.IP]; ok {
+			return nil, fmt.Errorf("duplicate desired entry for IP address: %s", entry.IP)
+		}
+		wanted[entry.IP] = entry
+		order = append(order, entry.IP)
+	}
+
+	plan := &Plan{Scope: scope}
+	matched := make(map[string]bool)
+
+	for _, entry := range h.Entries() {
+		if !scope.contains(entry) {
+			continue
+		}
+
+		target, ok := wanted[entry.IP]
+		if !ok || matched[entry.IP] {
+			// Not desired anymore, or a duplicate of an entry that was already matched
+			plan.Changes = append(plan.Changes, Change{Action: ActionRemove, Before: entry})
+			continue
+		}
+		matched[entry.IP] = true
+
+		target.Line = entry.Line
+		target.Block = entry.Block
+		if action, changed := changeAction(entry, target); changed {
+			plan.Changes = append(plan.Changes, Change{Action: action, Before: entry, After: target})
+		}
+	}
+
+	for _, ip := range order {
+		if !matched[ip] {
+			plan.Changes = append(plan.Changes, Change{Action: ActionAdd, After: wanted[ip]})
+		}
+	}
+
+	return plan, nil
+}
+
+// Apply applies the changes of the plan to the entries and saves the hosts file.
+// An empty plan leaves the hosts file untouched. If an entry the plan refers to
+// is no longer present, or the hosts file cannot be saved, no change is applied and
+// an error is returned.
+func (h *HostsFile) Apply(plan *Plan) error {
+	if plan.Empty() {
+		return nil
+	}
+
+	h.mu.Lock()
+	changes, backup, auditErr, err := h.apply(plan)
+	h.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return errors.Join(auditErr, h.runHooks(HookAfterSave, changes, backup))
+}
+
+// apply applies the changes of the plan and saves the hosts file, see Apply. It returns
+// the changes saved and the backup created, for the after save hooks, and the audit log error
+// as save does.
+func (h *HostsFile) apply(plan *Plan) (changes []Change, backup string, auditErr, err error) {
+	defer h.edit("Apply")()
+
+	entries := make([]HostEntry, len(h.entries))
+	copy(entries, h.entries)
+
+	for _, change := range plan.Changes {
+		if change.Action == ActionAdd {
+			entries = append(entries, change.After)
+			continue
+		}
+
+		i := indexOfEntry(entries, change.Before)
+		if i == -1 {
+			return nil, "", nil, fmt.Errorf("plan is out of date, %w: IP=%s, Hostname=%s", ErrEntryNotFound, change.Before.IP, change.Before.Hostnames)
+		}
+
+		if change.Action == ActionRemove {
+			entries = append(entries[:i], entries[i+1:]...)
+		} else {
+			entries[i] = change.After
+		}
+	}
+
+	// The plan is not kept applied if the hosts file cannot be saved
+	previous := h.entries
+	h.entries = entries
+	changes, backup, auditErr, err = h.save(h.auditReason)
+	if err != nil {
+		h.entries = previous
+		return nil, "", nil, err
+	}
+
+	return changes, backup, auditErr, nil
+}
+
+// indexOfEntry returns the index of the first entry equal to the provided entry
+// and parsed from the same line, or -1 if there is none.
+func indexOfEntry(entries []HostEntry, entry HostEntry) int {
+	for i := range entries {
+		if entries[i].Line == entry.Line && entries[i].Equal(entry) {
+			return i
+		}
+	}
+	return -1
+}
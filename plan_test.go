@@ -0,0 +1,237 @@
+package gohosts
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testPlanHosts = `127.0.0.1 localhost
+# gohosts:begin dev
+10.0.0.1 api.dev.local
+10.0.0.2 old.dev.local
+# 10.0.0.3 web.dev.local
+10.0.0.4 cache.dev.local
+# gohosts:end dev
+10.0.0.2 unmanaged.local
+`
+
+func TestPlan(t *testing.T) {
+	h, _ := newTestHostsFile(t, testPlanHosts)
+
+	desired := []HostEntry{
+		{IP: "10.0.0.1", Hostnames: []string{"api.dev.local", "api2.dev.local"}, Active: true},
+		{IP: "10.0.0.3", Hostnames: []string{"web.dev.local"}, Active: true},
+		{IP: "10.0.0.4", Hostnames: []string{"cache.dev.local"}, Active: false},
+		{IP: "10.0.0.5", Hostnames: []string{"new.dev.local"}, Active: true},
+	}
+
+	plan, err := h.Plan(Scope{Block: "dev"}, desired...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []ChangeAction{ActionUpdate, ActionRemove, ActionEnable, ActionDisable, ActionAdd}
+	if len(plan.Changes) != len(expected) {
+		t.Fatalf("expected %d changes, got %d:\n%s", len(expected), len(plan.Changes), plan)
+	}
+	for i, action := range expected {
+		if plan.Changes[i].Action != action {
+			t.Errorf("expected change %d to be %s, got %s", i, action, plan.Changes[i].Action)
+		}
+	}
+	if plan.Changes[1].Before.IP != "10.0.0.2" || plan.Changes[1].Before.Block != "dev" {
+		t.Errorf("expected the managed 10.0.0.2 entry to be removed, got %v", plan.Changes[1].Before)
+	}
+	if plan.Changes[4].After.Block != "dev" {
+		t.Errorf("expected the added entry to be in block dev, got %q", plan.Changes[4].After.Block)
+	}
+
+	rendered := plan.String()
+	if !strings.HasPrefix(rendered, `Plan for block "dev": 1 to add, 1 to update, 1 to remove, 1 to enable, 1 to disable`) {
+		t.Errorf("unexpected plan summary:\n%s", rendered)
+	}
+	if !strings.Contains(rendered, "+ 10.0.0.5     new.dev.local\n") {
+		t.Errorf("expected added entry in rendered plan:\n%s", rendered)
+	}
+
+	if err := h.Apply(plan); err != nil {
+		t.Fatalf("failed to apply plan: %v", err)
+	}
+
+	// Applying the same desired state again results in no changes
+	if err := h.Load(); err != nil {
+		t.Fatalf("failed to load hosts file: %v", err)
+	}
+	plan, err = h.Plan(Scope{Block: "dev"}, desired...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !plan.Empty() {
+		t.Errorf("expected an empty plan, got:\n%s", plan)
+	}
+
+	// The entries outside of the scope are untouched
+	found := false
+	for _, entry := range h.entries {
+		if entry.IP == "10.0.0.2" && entry.Block == "" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected the unmanaged entry to be kept")
+	}
+}
+
+func TestPlan_Tag(t *testing.T) {
+	h, _ := newTestHostsFile(t, "10.0.0.1 api.local # managed-by-cm\n10.0.0.2 other.local\n")
+
+	plan, err := h.Plan(Scope{Tag: "managed-by-cm"}, HostEntry{IP: "10.0.0.3", Hostnames: []string{"web.local"}, Active: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plan.Changes) != 2 {
+		t.Fatalf("expected 2 changes, got %d:\n%s", len(plan.Changes), plan)
+	}
+	if plan.Changes[1].After.Comment != "managed-by-cm" {
+		t.Errorf("expected the added entry to be tagged, got comment %q", plan.Changes[1].After.Comment)
+	}
+
+	if err := h.Apply(plan); err != nil {
+		t.Fatalf("failed to apply plan: %v", err)
+	}
+	if err := h.Load(); err != nil {
+		t.Fatalf("failed to load hosts file: %v", err)
+	}
+
+	plan, err = h.Plan(Scope{Tag: "managed-by-cm"}, HostEntry{IP: "10.0.0.3", Hostnames: []string{"web.local"}, Active: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !plan.Empty() {
+		t.Errorf("expected an empty plan, got:\n%s", plan)
+	}
+}
+
+func TestPlan_Invalid(t *testing.T) {
+	h := &HostsFile{}
+
+	// Test planning without a scope
+	_, err := h.Plan(Scope{})
+	if err == nil {
+		t.Error("Expected an error for empty scope")
+	}
+
+	// Test planning an entry with an invalid IP address
+	_, err = h.Plan(Scope{Block: "dev"}, HostEntry{IP: "invalid_ip", Hostnames: []string{"localhost"}})
+	if err == nil {
+		t.Error("Expected an error for entry with an invalid IP address")
+	}
+
+	// Test planning the same IP address twice
+	_, err = h.Plan(Scope{Block: "dev"},
+		HostEntry{IP: "10.0.0.1", Hostnames: []string{"a.local"}},
+		HostEntry{IP: "10.0.0.1", Hostnames: []string{"b.local"}},
+	)
+	if err == nil {
+		t.Error("Expected an error for duplicate desired entries")
+	}
+
+	// Test planning entries that would not be read back the same, as for imported entries
+	_, err = h.Plan(Scope{Tag: "managed"}, HostEntry{IP: "10.0.0.1", Hostnames: []string{"a.local"}, Block: "bad name"})
+	if !errors.Is(err, ErrInvalidBlock) {
+		t.Errorf("Expected ErrInvalidBlock for a block name with a space, got %v", err)
+	}
+	_, err = h.Plan(Scope{Block: "dev"}, HostEntry{IP: "10.0.0.1", Hostnames: []string{"a.local"}, Comment: "a\n10.6.6.6 evil.local"})
+	if !errors.Is(err, ErrInvalidComment) {
+		t.Errorf("Expected ErrInvalidComment for a comment with a line break, got %v", err)
+	}
+}
+
+func TestApply_OutOfDate(t *testing.T) {
+	h, _ := newTestHostsFile(t, testPlanHosts)
+
+	plan, err := h.Plan(Scope{Block: "dev"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	h.entries = h.entries[:1]
+	if err := h.Apply(plan); err == nil {
+		t.Error("Expected an error for out of date plan")
+	}
+}
+
+func TestApply_Vetoed(t *testing.T) {
+	h, _ := newTestHostsFile(t, testPlanHosts)
+	before := h.Entries()
+
+	plan, err := h.Plan(Scope{Block: "dev"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	h.OnBeforeSave(func(event HookEvent) error {
+		return errors.New("frozen")
+	})
+	if err := h.Apply(plan); err == nil {
+		t.Fatal("Expected an error for a vetoed save")
+	}
+
+	// The plan is not left applied in memory, for a later save to write it
+	if !Equal(h.Entries(), before) {
+		t.Errorf("expected the entries to be unchanged after a failed apply, got %v", h.Entries())
+	}
+	if len(h.History()) != 0 {
+		t.Errorf("expected no edit recorded for a failed apply, got %v", h.History())
+	}
+}
+
+func TestPlan_KeyValueTag(t *testing.T) {
+	h, _ := newTestHostsFile(t, "10.0.0.1 api.local # managed-by=cm\n10.0.0.2 other.local\n")
+
+	desired := HostEntry{IP: "10.0.0.1", Hostnames: []string{"api.local"}, Active: true}
+	plan, err := h.Plan(Scope{Tag: "managed-by=cm"}, desired)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !plan.Empty() {
+		t.Errorf("expected an empty plan, got:\n%s", plan)
+	}
+}
+
+func TestApply_WrappingFormatter(t *testing.T) {
+	for _, formatter := range []Formatter{{MaxHostnamesPerLine: 2}, {MaxLineLength: 30}} {
+		h, _ := newTestHostsFile(t, testPlanHosts, WithFormatter(formatter))
+		desired := HostEntry{IP: "10.0.0.1", Hostnames: []string{"api.dev.local", "api2.dev.local", "api3.dev.local"}, Active: true}
+
+		plan, err := h.Plan(Scope{Block: "dev"}, desired)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := h.Apply(plan); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// The entry is written over several lines, which are joined back on load
+		if err := h.Load(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		plan, err = h.Plan(Scope{Block: "dev"}, desired)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !plan.Empty() {
+			t.Errorf("expected an empty plan with %+v, got:\n%s", formatter, plan)
+		}
+
+		// Nor are false changes reported for the audit log and the hooks
+		disk, err := h.readEntries(h.path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if changes := Diff(disk, h.Entries()); len(changes) != 0 {
+			t.Errorf("expected no changes from the file with %+v, got %v", formatter, changes)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package gohosts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// ProfileBlock is the name of the managed block holding the entries of the active profile.
+	ProfileBlock = "gohosts-profile"
+
+	// profileExt is the file extension of the profile files in the profiles directory.
+	profileExt = ".json"
+	// currentProfileFile is the file in the profiles directory recording the active profile.
+	currentProfileFile = "current"
+)
+
+// profileNameRegexp matches the allowed profile names.
+var profileNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// WithProfileDir is a HostsOption that sets the directory where profiles are stored.
+// By default profiles are stored in the gohosts/profiles directory of the user configuration directory.
+func WithProfileDir(dir string) HostsOption {
+	return func(h *HostsFile) {
+		h.profileDir = dir
+	}
+}
+
+// profilesDir returns the directory where profiles are stored.
+func (h *HostsFile) profilesDir() (string, error) {
+	if h.profileDir != "" {
+		return h.profileDir, nil
+	}
+
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find profiles directory: %w", err)
+	}
+	return filepath.Join(configDir, "gohosts", "profiles"), nil
+}
+
+// profilePath returns the path of the file storing the named profile.
+func (h *HostsFile) profilePath(name string) (string, error) {
+	if !profileNameRegexp.MatchString(name) {
+		return "", fmt.Errorf("invalid profile name: %s", name)
+	}
+
+	dir, err := h.profilesDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name+profileExt), nil
+}
+
+// SaveProfile stores the provided entries as the named profile, replacing any existing profile
+// with the same name. The hosts file itself is not modified.
+func (h *HostsFile) SaveProfile(name string, entries ...HostEntry) error {
+	path, err := h.profilePath(name)
+	if err != nil {
+		return err
+	}
+
+	profile := make([]HostEntry, len(entries))
+	for i, entry := range entries {
+		entry.Line = 0
+		entry.Block = ""
+		if err := h.validateImportedEntry(&entry); err != nil {
+			return err
+		}
+		profile[i] = entry
+	}
+
+	data, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := h.filesystem().MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create profiles directory: %w", err)
+	}
+	if err := writeFileAtomic(h.filesystem(), path, append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to save profile: %w", err)
+	}
+
+	return nil
+}
+
+// LoadProfile returns the entries of the named profile.
+func (h *HostsFile) LoadProfile(name string) ([]HostEntry, error) {
+	path, err := h.profilePath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := readFile(h.filesystem(), path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrProfileNotFound, name)
+		}
+		return nil, fmt.Errorf("failed to read profile: %w", err)
+	}
+
+	var entries []HostEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to decode profile %s: %w", name, err)
+	}
+	for i := range entries {
+		if err := h.validateImportedEntry(&entries[i]); err != nil {
+			return nil, fmt.Errorf("invalid profile %s: %w", name, err)
+		}
+	}
+
+	return entries, nil
+}
+
+// ListProfiles returns the names of the stored profiles, sorted alphabetically.
+func (h *HostsFile) ListProfiles() ([]string, error) {
+	dir, err := h.profilesDir()
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := h.filesystem().ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var profiles []string
+	for _, file := range files {
+		name := strings.TrimSuffix(file.Name(), profileExt)
+		if !file.IsDir() && strings.HasSuffix(file.Name(), profileExt) && profileNameRegexp.MatchString(name) {
+			profiles = append(profiles, name)
+		}
+	}
+	sort.Strings(profiles)
+
+	return profiles, nil
+}
+
+// CurrentProfile returns the name of the active profile, or an empty string if no profile
+// has been activated.
+func (h *HostsFile) CurrentProfile() (string, error) {
+	dir, err := h.profilesDir()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := readFile(h.filesystem(), filepath.Join(dir, currentProfileFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read current profile: %w", err)
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+// ActivateProfile replaces the entries of the ProfileBlock block with the entries of the named
+// profile, saves the hosts file and records the profile as the active one.
+// The entries outside of the block are left untouched.
+func (h *HostsFile) ActivateProfile(name string) error {
+	profile, err := h.LoadProfile(name)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	changes, backup, recordErr, err := h.activateProfile(name, profile)
+	h.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return errors.Join(recordErr, h.runHooks(HookAfterSave, changes, backup))
+}
+
+// activateProfile replaces the entries of the ProfileBlock block with the profile entries, saves
+// the hosts file and records the profile as the active one, see ActivateProfile. It returns the
+// changes saved and the backup created, for the after save hooks. Once the hosts file is saved,
+// a failure to write the audit log or to record the profile is returned as recordErr.
+func (h *HostsFile) activateProfile(name string, profile []HostEntry) (changes []Change, backup string, recordErr, err error) {
+	defer h.edit("ActivateProfile")()
+
+	var entries []HostEntry
+	for _, entry := range h.entries {
+		if entry.Block != ProfileBlock {
+			entries = append(entries, entry)
+		}
+	}
+	for _, entry := range profile {
+		entry.Block = ProfileBlock
+		entries = append(entries, entry)
+	}
+
+	previous := h.entries
+	h.entries = entries
+	changes, backup, auditErr, err := h.save(h.auditReason)
+	if err != nil {
+		h.entries = previous
+		return nil, "", nil, err
+	}
+
+	dir, err := h.profilesDir()
+	if err == nil {
+		err = writeFileAtomic(h.filesystem(), filepath.Join(dir, currentProfileFile), []byte(name+"\n"))
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to record current profile: %w", err)
+	}
+	return changes, backup, errors.Join(auditErr, err), nil
+}
@@ -0,0 +1,107 @@
+package gohosts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProfiles(t *testing.T) {
+	h, _ := newTestHostsFile(t, "127.0.0.1 localhost\n")
+
+	profiles, err := h.ListProfiles()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(profiles) != 0 {
+		t.Errorf("expected no profiles, got %v", profiles)
+	}
+
+	current, err := h.CurrentProfile()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if current != "" {
+		t.Errorf("expected no current profile, got %s", current)
+	}
+
+	err = h.SaveProfile("staging", HostEntry{IP: "10.0.1.1", Hostnames: []string{"api.example.com"}, Active: true})
+	if err != nil {
+		t.Fatalf("failed to save profile: %v", err)
+	}
+	err = h.SaveProfile("local",
+		HostEntry{IP: "127.0.0.1", Hostnames: []string{"api.example.com"}, Active: true},
+		HostEntry{IP: "127.0.0.1", Hostnames: []string{"web.example.com"}, Active: true},
+	)
+	if err != nil {
+		t.Fatalf("failed to save profile: %v", err)
+	}
+
+	profiles, err = h.ListProfiles()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(profiles, ",") != "local,staging" {
+		t.Errorf("expected profiles [local staging], got %v", profiles)
+	}
+
+	// Activating a profile replaces the profile block
+	for _, name := range []string{"local", "staging"} {
+		if err := h.ActivateProfile(name); err != nil {
+			t.Fatalf("failed to activate profile %s: %v", name, err)
+		}
+	}
+
+	if err := h.Load(); err != nil {
+		t.Fatalf("failed to load hosts file: %v", err)
+	}
+	expected := []HostEntry{
+		{IP: "127.0.0.1", Hostnames: []string{"localhost"}, Active: true},
+		{IP: "10.0.1.1", Hostnames: []string{"api.example.com"}, Active: true, Block: ProfileBlock},
+	}
+	if !Equal(h.entries, expected) {
+		t.Errorf("Entries do not match expected entries. \nGot: %v, \nExpected: %v", h.entries, expected)
+	}
+
+	current, err = h.CurrentProfile()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if current != "staging" {
+		t.Errorf("expected current profile staging, got %s", current)
+	}
+}
+
+func TestLoadProfile_DefaultActive(t *testing.T) {
+	h := &HostsFile{profileDir: t.TempDir()}
+
+	err := os.WriteFile(filepath.Join(h.profileDir, "prod.json"), []byte(`[{"ip": "10.0.2.1", "hostnames": ["api.example.com"]}]`), 0644)
+	if err != nil {
+		t.Fatalf("failed to write profile: %v", err)
+	}
+
+	entries, err := h.LoadProfile("prod")
+	if err != nil {
+		t.Fatalf("failed to load profile: %v", err)
+	}
+	if len(entries) != 1 || !entries[0].Active {
+		t.Errorf("expected 1 active entry, got %v", entries)
+	}
+}
+
+func TestActivateProfile_Invalid(t *testing.T) {
+	h := &HostsFile{profileDir: t.TempDir()}
+
+	// Test activating a profile that does not exist
+	err := h.ActivateProfile("missing")
+	if err == nil {
+		t.Error("Expected an error for missing profile")
+	}
+
+	// Test activating a profile with an invalid name
+	err = h.ActivateProfile("../hosts")
+	if err == nil {
+		t.Error("Expected an error for invalid profile name")
+	}
+}
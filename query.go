@@ -0,0 +1,307 @@
+package gohosts
+
+import (
+	"fmt"
+	"net/netip"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Predicate reports whether a host entry is selected.
+//
+// Predicates are evaluated once per hostname, on a copy of the entry holding only that hostname.
+// This way predicates on hostnames select single hostnames of an entry, while predicates on
+// the rest of the entry select all of its hostnames. Predicates run with the hosts file locked,
+// they must not call the methods of the hosts file.
+type Predicate func(entry HostEntry) bool
+
+// And returns a predicate selecting the entries selected by all the provided predicates.
+func And(preds ...Predicate) Predicate {
+	return func(entry HostEntry) bool {
+		for _, pred := range preds {
+			if !pred(entry) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or returns a predicate selecting the entries selected by any of the provided predicates.
+func Or(preds ...Predicate) Predicate {
+	return func(entry HostEntry) bool {
+		for _, pred := range preds {
+			if pred(entry) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not returns a predicate selecting the entries not selected by the provided predicate.
+func Not(pred Predicate) Predicate {
+	return func(entry HostEntry) bool {
+		return !pred(entry)
+	}
+}
+
+// IPIs returns a predicate selecting the entries with the provided IP address, in any of its
+// forms: "::0001" selects "::1" and "::ffff:10.0.0.1" selects "10.0.0.1". IPv6 zones must match.
+func IPIs(ip string) Predicate {
+	parsed, err := netip.ParseAddr(ip)
+	return func(entry HostEntry) bool {
+		addr := entry.Addr()
+		return err == nil && addr.IsValid() && addr.Unmap() == parsed.Unmap()
+	}
+}
+
+// InCIDR returns a predicate selecting the entries with an IP address in the provided network,
+// such as "10.0.0.0/8".
+func InCIDR(cidr string) (Predicate, error) {
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CIDR: %s", cidr)
+	}
+
+	return func(entry HostEntry) bool {
+		addr, err := netip.ParseAddr(entry.IP)
+		if err != nil {
+			return false
+		}
+		if prefix.Addr().Is4() {
+			addr = addr.Unmap()
+		}
+		return prefix.Contains(addr.WithZone(""))
+	}, nil
+}
+
+// Hostname returns a predicate selecting the provided hostname, compared case-insensitively.
+func Hostname(hostname string) Predicate {
+	return hostnamePredicate(func(name string) bool {
+		return equalHostnames(name, hostname)
+	})
+}
+
+// HostnameGlob returns a predicate selecting the hostnames matching the provided glob pattern,
+// such as "*.dev.local". The pattern syntax is the one of path.Match, compared case-insensitively.
+func HostnameGlob(pattern string) (Predicate, error) {
+	pattern = strings.ToLower(pattern)
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid glob pattern: %s", pattern)
+	}
+
+	return hostnamePredicate(func(name string) bool {
+		matched, _ := path.Match(pattern, strings.ToLower(name))
+		return matched
+	}), nil
+}
+
+// HostnameSuffix returns a predicate selecting the hostnames equal to the provided domain or
+// in one of its subdomains, so "dev.local" selects "dev.local" and "api.dev.local".
+func HostnameSuffix(domain string) Predicate {
+	domain = normalizeHostname(strings.TrimPrefix(domain, "."))
+	return hostnamePredicate(func(name string) bool {
+		name = normalizeHostname(name)
+		return name == domain || strings.HasSuffix(name, "."+domain)
+	})
+}
+
+// HostnameRegexp returns a predicate selecting the hostnames matching the provided regular expression.
+func HostnameRegexp(re *regexp.Regexp) Predicate {
+	return hostnamePredicate(re.MatchString)
+}
+
+// CommentContains returns a predicate selecting the entries with a comment containing the provided text.
+func CommentContains(text string) Predicate {
+	return func(entry HostEntry) bool {
+		return strings.Contains(entry.Comment, text)
+	}
+}
+
+// Tagged returns a predicate selecting the entries having the tag with the provided key,
+// and the provided value if any.
+func Tagged(key string, value ...string) Predicate {
+	return func(entry HostEntry) bool {
+		return entry.HasTag(key, value...)
+	}
+}
+
+// InBlock returns a predicate selecting the entries of the named managed block.
+func InBlock(name string) Predicate {
+	return func(entry HostEntry) bool {
+		return entry.Block == name
+	}
+}
+
+// IsActive returns a predicate selecting the entries with the provided active state.
+func IsActive(active bool) Predicate {
+	return func(entry HostEntry) bool {
+		return entry.Active == active
+	}
+}
+
+// hostnamePredicate returns a predicate selecting the entries with a hostname matching the
+// provided function. Internationalized hostnames are matched in both their Unicode and punycode form.
+func hostnamePredicate(match func(hostname string) bool) Predicate {
+	return func(entry HostEntry) bool {
+		for _, hostname := range entry.Hostnames {
+			for _, form := range hostnameForms(hostname) {
+				if match(form) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
+
+// splitHostnames evaluates the predicate for each hostname of the entry and returns the
+// matched and unmatched hostnames.
+func splitHostnames(entry HostEntry, pred Predicate) (matched, unmatched []string) {
+	for _, hostname := range entry.Hostnames {
+		single := entry
+		single.Hostnames = []string{hostname}
+		if pred(single) {
+			matched = append(matched, hostname)
+		} else {
+			unmatched = append(unmatched, hostname)
+		}
+	}
+	return matched, unmatched
+}
+
+// Filter returns the entries selected by the predicate. When only some hostnames of an entry
+// are selected, the returned entry holds only those hostnames.
+func (h *HostsFile) Filter(pred Predicate) []HostEntry {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var entries []HostEntry
+	for _, entry := range h.entries {
+		matched, _ := splitHostnames(entry, pred)
+		if len(matched) > 0 {
+			entry.Hostnames = matched
+			entry.Tags = copyTags(entry.Tags)
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// RemoveWhere removes the hostnames selected by the predicate, entries left without hostnames
+// are removed. It returns the number of changed or removed entries.
+func (h *HostsFile) RemoveWhere(pred Predicate) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	defer h.edit("RemoveWhere")()
+
+	var entries []HostEntry
+	affected := 0
+
+	for _, entry := range h.entries {
+		matched, unmatched := splitHostnames(entry, pred)
+		if len(matched) > 0 {
+			affected++
+		}
+		if len(unmatched) > 0 {
+			entry.Hostnames = unmatched
+			entries = append(entries, entry)
+		}
+	}
+
+	h.entries = entries
+	return affected
+}
+
+// DisableWhere comments out the active hostnames selected by the predicate. When only some
+// hostnames of an entry are selected, they are moved to a disabled entry right after it.
+// It returns the number of changed entries.
+func (h *HostsFile) DisableWhere(pred Predicate) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	defer h.edit("DisableWhere")()
+
+	entries, affected := h.splitWhere(And(IsActive(true), pred), func(entry *HostEntry) {
+		entry.Active = false
+	})
+
+	h.entries = entries
+	return affected
+}
+
+// EnableWhere uncomments the disabled hostnames selected by the predicate. When only some
+// hostnames of an entry are selected, they are moved to an active entry right after it.
+// It returns the number of changed entries.
+func (h *HostsFile) EnableWhere(pred Predicate) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	defer h.edit("EnableWhere")()
+
+	entries, affected := h.splitWhere(And(IsActive(false), pred), func(entry *HostEntry) {
+		entry.Active = true
+	})
+
+	h.entries = entries
+	return affected
+}
+
+// UpdateWhere calls update for the entries selected by the predicate. When only some hostnames
+// of an entry are selected, they are moved to a new entry right after it, which is then updated.
+// If an updated entry is invalid, such as a hostname rejected by the hostname validator or a block
+// name with whitespace, no entry is changed and an error is returned.
+// It returns the number of changed entries. The update function runs with the hosts file locked,
+// it must not call the methods of the hosts file.
+func (h *HostsFile) UpdateWhere(pred Predicate, update func(entry *HostEntry)) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	defer h.edit("UpdateWhere")()
+
+	// Only the updated entries are checked, the other ones are kept as they were parsed
+	var err error
+	entries, affected := h.splitWhere(pred, func(entry *HostEntry) {
+		update(entry)
+		if err == nil {
+			err = h.validateImportedEntry(entry)
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	h.entries = entries
+	return affected, nil
+}
+
+// splitWhere returns a copy of the entries where the hostnames selected by the predicate are
+// changed by the provided function, along with the number of changed entries.
+func (h *HostsFile) splitWhere(pred Predicate, change func(entry *HostEntry)) ([]HostEntry, int) {
+	var entries []HostEntry
+	affected := 0
+
+	for _, entry := range h.entries {
+		matched, unmatched := splitHostnames(entry, pred)
+		if len(matched) == 0 {
+			entries = append(entries, entry)
+			continue
+		}
+		affected++
+
+		if len(unmatched) > 0 {
+			// Keep the unmatched hostnames on the original line
+			kept := entry
+			kept.Hostnames = unmatched
+			entries = append(entries, kept)
+
+			entry.Line = 0
+		}
+
+		entry.Hostnames = append([]string(nil), matched...)
+		entry.Tags = copyTags(entry.Tags)
+		change(&entry)
+		entries = append(entries, entry)
+	}
+
+	return entries, affected
+}
@@ -0,0 +1,211 @@
+package gohosts
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+)
+
+const testQueryHosts = `127.0.0.1 localhost
+10.0.0.1 api.dev.local api.prod.local # api servers
+# 10.0.1.1 web.dev.local # owner=web
+192.168.0.1 router
+`
+
+func TestFilter(t *testing.T) {
+	h, _ := newTestHostsFile(t, testQueryHosts)
+
+	cidr, err := InCIDR("10.0.0.0/16")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	glob, err := HostnameGlob("*.dev.local")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		pred     Predicate
+		expected []HostEntry
+	}{
+		{
+			name: "cidr",
+			pred: cidr,
+			expected: []HostEntry{
+				{IP: "10.0.0.1", Hostnames: []string{"api.dev.local", "api.prod.local"}, Comment: "api servers", Active: true},
+				{IP: "10.0.1.1", Hostnames: []string{"web.dev.local"}, Tags: map[string]string{"owner": "web"}, Active: false},
+			},
+		},
+		{
+			name: "glob",
+			pred: glob,
+			expected: []HostEntry{
+				{IP: "10.0.0.1", Hostnames: []string{"api.dev.local"}, Comment: "api servers", Active: true},
+				{IP: "10.0.1.1", Hostnames: []string{"web.dev.local"}, Tags: map[string]string{"owner": "web"}, Active: false},
+			},
+		},
+		{
+			name: "suffix and active",
+			pred: And(HostnameSuffix("dev.local"), IsActive(true)),
+			expected: []HostEntry{
+				{IP: "10.0.0.1", Hostnames: []string{"api.dev.local"}, Comment: "api servers", Active: true},
+			},
+		},
+		{
+			name: "regexp or tag",
+			pred: Or(HostnameRegexp(regexp.MustCompile(`^api\.prod\.`)), Tagged("owner", "web")),
+			expected: []HostEntry{
+				{IP: "10.0.0.1", Hostnames: []string{"api.prod.local"}, Comment: "api servers", Active: true},
+				{IP: "10.0.1.1", Hostnames: []string{"web.dev.local"}, Tags: map[string]string{"owner": "web"}, Active: false},
+			},
+		},
+		{
+			name: "not comment",
+			pred: Not(Or(CommentContains("api"), IPIs("10.0.1.1"))),
+			expected: []HostEntry{
+				{IP: "127.0.0.1", Hostnames: []string{"localhost"}, Active: true},
+				{IP: "192.168.0.1", Hostnames: []string{"router"}, Active: true},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entries := h.Filter(test.pred)
+			if !Equal(entries, test.expected) {
+				t.Errorf("Entries do not match expected entries. \nGot: %v, \nExpected: %v", entries, test.expected)
+			}
+		})
+	}
+}
+
+func TestFilter_InvalidPatterns(t *testing.T) {
+	if _, err := InCIDR("10.0.0.0/33"); err == nil {
+		t.Error("Expected an error for invalid CIDR")
+	}
+	if _, err := HostnameGlob("[a-"); err == nil {
+		t.Error("Expected an error for invalid glob pattern")
+	}
+}
+
+func TestRemoveWhere(t *testing.T) {
+	h, _ := newTestHostsFile(t, testQueryHosts)
+
+	removed := h.RemoveWhere(HostnameSuffix("dev.local"))
+	if removed != 2 {
+		t.Errorf("expected 2 affected entries, got %d", removed)
+	}
+
+	expected := []HostEntry{
+		{IP: "127.0.0.1", Hostnames: []string{"localhost"}, Active: true},
+		{IP: "10.0.0.1", Hostnames: []string{"api.prod.local"}, Comment: "api servers", Active: true},
+		{IP: "192.168.0.1", Hostnames: []string{"router"}, Active: true},
+	}
+	if !Equal(h.entries, expected) {
+		t.Errorf("Entries do not match expected entries. \nGot: %v, \nExpected: %v", h.entries, expected)
+	}
+}
+
+func TestDisableWhere(t *testing.T) {
+	h, _ := newTestHostsFile(t, testQueryHosts)
+
+	disabled := h.DisableWhere(HostnameSuffix("dev.local"))
+	if disabled != 1 {
+		t.Errorf("expected 1 affected entry, got %d", disabled)
+	}
+
+	expected := []HostEntry{
+		{IP: "127.0.0.1", Hostnames: []string{"localhost"}, Active: true},
+		{IP: "10.0.0.1", Hostnames: []string{"api.prod.local"}, Comment: "api servers", Active: true},
+		{IP: "10.0.0.1", Hostnames: []string{"api.dev.local"}, Comment: "api servers", Active: false},
+		{IP: "10.0.1.1", Hostnames: []string{"web.dev.local"}, Tags: map[string]string{"owner": "web"}, Active: false},
+		{IP: "192.168.0.1", Hostnames: []string{"router"}, Active: true},
+	}
+	if !Equal(h.entries, expected) {
+		t.Errorf("Entries do not match expected entries. \nGot: %v, \nExpected: %v", h.entries, expected)
+	}
+	if h.entries[1].Line != 2 || h.entries[2].Line != 0 {
+		t.Errorf("expected the split entry to keep its line, got lines %d and %d", h.entries[1].Line, h.entries[2].Line)
+	}
+
+	enabled := h.EnableWhere(Hostname("web.dev.local"))
+	if enabled != 1 || !h.entries[3].Active {
+		t.Errorf("expected web.dev.local to be enabled, got %v", h.entries)
+	}
+}
+
+func TestUpdateWhere(t *testing.T) {
+	h, _ := newTestHostsFile(t, testQueryHosts)
+
+	updated, err := h.UpdateWhere(Hostname("api.dev.local"), func(entry *HostEntry) {
+		entry.IP = "10.0.0.2"
+		entry.Tags = map[string]string{"moved": "yes"}
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated != 1 {
+		t.Errorf("expected 1 affected entry, got %d", updated)
+	}
+
+	expected := []HostEntry{
+		{IP: "10.0.0.1", Hostnames: []string{"api.prod.local"}, Comment: "api servers", Active: true},
+		{IP: "10.0.0.2", Hostnames: []string{"api.dev.local"}, Comment: "api servers", Tags: map[string]string{"moved": "yes"}, Active: true},
+	}
+	if !Equal(h.entries[1:3], expected) {
+		t.Errorf("Entries do not match expected entries. \nGot: %v, \nExpected: %v", h.entries[1:3], expected)
+	}
+
+	// Test updating to an invalid IP address
+	_, err = h.UpdateWhere(Hostname("router"), func(entry *HostEntry) {
+		entry.IP = "invalid_ip"
+	})
+	if err == nil {
+		t.Error("Expected an error for update to an invalid IP address")
+	}
+	if h.entries[len(h.entries)-1].IP != "192.168.0.1" {
+		t.Error("expected the entries to be unchanged after a failed update")
+	}
+
+	// Test updating to a hostname and a block that would not be read back the same
+	_, err = h.UpdateWhere(Hostname("router"), func(entry *HostEntry) {
+		entry.Hostnames = []string{"b.local # x"}
+	})
+	if !errors.Is(err, ErrInvalidHostname) {
+		t.Errorf("Expected ErrInvalidHostname for update to an invalid hostname, got %v", err)
+	}
+	_, err = h.UpdateWhere(Hostname("router"), func(entry *HostEntry) {
+		entry.Block = "my block"
+	})
+	if !errors.Is(err, ErrInvalidBlock) {
+		t.Errorf("Expected ErrInvalidBlock for update to a block name with a space, got %v", err)
+	}
+	if last := h.entries[len(h.entries)-1]; last.Hostnames[0] != "router" || last.Block != "" {
+		t.Error("expected the entries to be unchanged after a failed update")
+	}
+
+	// Test updating to a hostname rejected by the hostname validator of the hosts file
+	h.hostnameValidator = func(hostname string) bool { return hostname != "blocked.local" }
+	_, err = h.UpdateWhere(Hostname("router"), func(entry *HostEntry) {
+		entry.Hostnames = []string{"blocked.local"}
+	})
+	if !errors.Is(err, ErrInvalidHostname) {
+		t.Errorf("Expected ErrInvalidHostname for update to a rejected hostname, got %v", err)
+	}
+}
+
+func TestUpdateWhere_InvalidUnchanged(t *testing.T) {
+	// The parser keeps hostnames the validator rejects, they do not fail the update of other entries
+	h, _ := newTestHostsFile(t, "10.0.0.1 _acme.local\n10.0.0.2 web.local\n")
+
+	updated, err := h.UpdateWhere(Hostname("web.local"), func(entry *HostEntry) {
+		entry.Comment = "web server"
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated != 1 || h.entries[1].Comment != "web server" || h.entries[0].Hostnames[0] != "_acme.local" {
+		t.Errorf("unexpected entries after update: %v", h.entries)
+	}
+}
@@ -0,0 +1,153 @@
+package gohosts
+
+import (
+	"io"
+	"strings"
+)
+
+// Snapshot is an immutable view of the content of a hosts file at the time it was taken.
+// It shares the entries of the hosts file instead of copying them, which makes it cheap to take,
+// and is safe to hand to other goroutines: later changes to the hosts file do not affect it.
+type Snapshot struct {
+	// file holds the content of the snapshot and is never changed
+	file *HostsFile
+}
+
+// Snapshot returns an immutable view of the current content of the hosts file.
+func (h *HostsFile) Snapshot() *Snapshot {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	// Entries are never changed in place, the hosts file replaces them, so the snapshot can share
+	// them. The capacity is clipped so appending to the hosts file entries reallocates them.
+	return &Snapshot{file: &HostsFile{
+		formatter:         h.formatter,
+		encoding:          h.encoding,
+		additionalContent: h.additionalContent,
+		entries:           clip(h.entries),
+	}}
+}
+
+// Len returns the number of entries in the snapshot.
+func (s *Snapshot) Len() int {
+	return len(s.file.entries)
+}
+
+// Entries returns a copy of the entries of the snapshot, in order.
+func (s *Snapshot) Entries() []HostEntry {
+	return s.file.Entries()
+}
+
+// AdditionalContent returns the content written before the entries, see HostsFile.AdditionalContent.
+func (s *Snapshot) AdditionalContent() string {
+	return s.file.additionalContent
+}
+
+// Encoding returns the encoding of the snapshot, see HostsFile.Encoding.
+func (s *Snapshot) Encoding() Encoding {
+	return s.file.Encoding()
+}
+
+// Blocks returns the names of the managed blocks of the snapshot, in the order they first appear.
+func (s *Snapshot) Blocks() []string {
+	return s.file.Blocks()
+}
+
+// Filter returns copies of the entries selected by the predicate, see HostsFile.Filter.
+func (s *Snapshot) Filter(pred Predicate) []HostEntry {
+	return s.file.Filter(pred)
+}
+
+// FindByTag returns copies of the entries having the tag with the provided key, and the provided
+// value if any.
+func (s *Snapshot) FindByTag(key string, value ...string) []HostEntry {
+	return s.file.FindByTag(key, value...)
+}
+
+// LookupHost returns the IP addresses the active entries map the hostname to, in the order they
+// appear, without duplicates. A resolver uses the first address of each address family.
+func (s *Snapshot) LookupHost(hostname string) []string {
+	var addrs []string
+	for _, entry := range s.Filter(And(Hostname(hostname), IsActive(true))) {
+		if !contains(addrs, entry.IP) {
+			addrs = append(addrs, entry.IP)
+		}
+	}
+	return addrs
+}
+
+// LookupAddr returns the hostnames the active entries map to the IP address, in the order they
+// appear, without duplicates. The IP address is matched in any of its forms, see IPIs.
+func (s *Snapshot) LookupAddr(ip string) []string {
+	var hostnames []string
+	for _, entry := range s.Filter(And(IPIs(ip), IsActive(true))) {
+		for _, hostname := range entry.Hostnames {
+			if !containsHostnames(hostnames, hostname) {
+				hostnames = append(hostnames, hostname)
+			}
+		}
+	}
+	return hostnames
+}
+
+// Format writes the content of the snapshot, as the hosts file would be saved, to the provided writer.
+func (s *Snapshot) Format(w io.Writer) error {
+	return s.file.Format(w)
+}
+
+// WriteTo writes the content of the snapshot to w. It implements the io.WriterTo interface.
+func (s *Snapshot) WriteTo(w io.Writer) (int64, error) {
+	return s.file.WriteTo(w)
+}
+
+// String returns the content of the snapshot, as the hosts file would be saved.
+func (s *Snapshot) String() string {
+	var b strings.Builder
+	s.file.Format(&b)
+	return b.String()
+}
+
+// Equal checks if the snapshot has the same additional content and entries as the other snapshot,
+// see Equal.
+func (s *Snapshot) Equal(other *Snapshot) bool {
+	return s.file.additionalContent == other.file.additionalContent && Equal(s.file.entries, other.file.entries)
+}
+
+// Clone returns a deep copy of the hosts file, independent from it: changing one does not change
+// the other. The copy has the same options, content, hooks and history.
+func (h *HostsFile) Clone() *HostsFile {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	clone := &HostsFile{
+		path:              h.path,
+		createIfMissing:   h.createIfMissing,
+		fsys:              h.fsys,
+		clock:             h.clock,
+		profileDir:        h.profileDir,
+		autoPrune:         h.autoPrune,
+		formatter:         h.formatter,
+		conflictPolicy:    h.conflictPolicy,
+		hostnameValidator: h.hostnameValidator,
+		ipv4MappedPolicy:  h.ipv4MappedPolicy,
+		auditLog:          h.auditLog,
+		historyDepth:      h.historyDepth,
+		auditReason:       h.auditReason,
+		encoding:          h.encoding,
+		skipped:           append([]skippedLine(nil), h.skipped...),
+		entries:           cloneEntries(h.entries),
+		additionalContent: h.additionalContent,
+		undo:              append([]Edit(nil), h.undo...),
+		redo:              append([]Edit(nil), h.redo...),
+	}
+
+	h.hooksMu.Lock()
+	defer h.hooksMu.Unlock()
+	for hookType, hooks := range h.hooks {
+		for _, hook := range hooks {
+			clone.addHook(hookType, hook)
+		}
+	}
+
+	return clone
+}
@@ -0,0 +1,221 @@
+package gohosts
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testSnapshotHosts = `# header
+127.0.0.1 localhost
+::1 localhost
+10.0.0.1 api.local www.local # owner=web
+# 10.0.0.2 api.local
+10.0.0.3 api.local
+fe80::1 api.local
+# gohosts:begin dev
+10.0.0.4 web.dev.local
+# gohosts:end dev
+`
+
+func TestSnapshot(t *testing.T) {
+	h, _ := newTestHostsFile(t, testSnapshotHosts)
+	snapshot := h.Snapshot()
+	expected := snapshot.String()
+
+	// Changing the hosts file does not change the snapshot
+	if err := h.Remove("10.0.0.1", []string{"www.local"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := h.Add("10.0.0.5", []string{"new.local"}, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h.DisableWhere(Hostname("localhost"))
+	h.UpdateWhere(InBlock("dev"), func(entry *HostEntry) {
+		entry.Comment = "updated"
+	})
+	h.SetAdditionalContent("")
+
+	if snapshot.Len() != 7 {
+		t.Errorf("expected 7 entries, got %d", snapshot.Len())
+	}
+	if snapshot.String() != expected {
+		t.Errorf("unexpected content:\n%s", snapshot.String())
+	}
+
+	var b bytes.Buffer
+	if _, err := snapshot.WriteTo(&b); err != nil || b.String() != expected {
+		t.Errorf("unexpected content: %q, %v", b.String(), err)
+	}
+
+	// Changing the returned entries does not change the snapshot
+	entries := snapshot.Entries()
+	entries[0].Hostnames[0] = "changed.local"
+	entries[2].Tags["owner"] = "changed"
+	if snapshot.Entries()[0].Hostnames[0] != "localhost" || snapshot.Entries()[2].Tags["owner"] != "web" {
+		t.Errorf("expected the snapshot entries to be unchanged, got %v", snapshot.Entries())
+	}
+
+	if blocks := snapshot.Blocks(); len(blocks) != 1 || blocks[0] != "dev" {
+		t.Errorf("unexpected blocks: %v", blocks)
+	}
+	if found := snapshot.FindByTag("owner", "web"); len(found) != 1 || found[0].IP != "10.0.0.1" {
+		t.Errorf("unexpected entries: %v", found)
+	}
+	if found := snapshot.Filter(IsActive(false)); len(found) != 1 || found[0].IP != "10.0.0.2" {
+		t.Errorf("unexpected entries: %v", found)
+	}
+	if snapshot.AdditionalContent() != "# header\n" {
+		t.Errorf("unexpected additional content: %q", snapshot.AdditionalContent())
+	}
+	if snapshot.Encoding().LineEnding != "\n" {
+		t.Errorf("unexpected encoding: %v", snapshot.Encoding())
+	}
+}
+
+func TestSnapshot_Lookup(t *testing.T) {
+	h, _ := newTestHostsFile(t, testSnapshotHosts)
+	snapshot := h.Snapshot()
+
+	tests := []struct {
+		hostname string
+		expected []string
+	}{
+		{"api.local", []string{"10.0.0.1", "10.0.0.3", "fe80::1"}},
+		{"API.local.", []string{"10.0.0.1", "10.0.0.3", "fe80::1"}},
+		{"localhost", []string{"127.0.0.1", "::1"}},
+		{"missing.local", nil},
+	}
+	for _, test := range tests {
+		if addrs := snapshot.LookupHost(test.hostname); !equalStrings(addrs, test.expected) {
+			t.Errorf("LookupHost(%s) = %v, expected %v", test.hostname, addrs, test.expected)
+		}
+	}
+
+	if hostnames := snapshot.LookupAddr("10.0.0.1"); !equalStrings(hostnames, []string{"api.local", "www.local"}) {
+		t.Errorf("unexpected hostnames: %v", hostnames)
+	}
+	// Disabled entries are not resolved
+	if hostnames := snapshot.LookupAddr("10.0.0.2"); len(hostnames) != 0 {
+		t.Errorf("unexpected hostnames: %v", hostnames)
+	}
+	if hostnames := snapshot.LookupAddr("::ffff:127.0.0.1"); !equalStrings(hostnames, []string{"localhost"}) {
+		t.Errorf("unexpected hostnames: %v", hostnames)
+	}
+}
+
+func TestSnapshot_Equal(t *testing.T) {
+	h, _ := newTestHostsFile(t, testSnapshotHosts)
+	before := h.Snapshot()
+
+	if !before.Equal(h.Snapshot()) {
+		t.Errorf("expected snapshots of the same content to be equal")
+	}
+
+	if err := h.Add("10.0.0.5", []string{"new.local"}, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if before.Equal(h.Snapshot()) {
+		t.Errorf("expected snapshots of different entries not to be equal")
+	}
+
+	if err := h.Remove("10.0.0.5", []string{"new.local"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !before.Equal(h.Snapshot()) {
+		t.Errorf("expected snapshots of the same content to be equal")
+	}
+
+	h.SetAdditionalContent("# another header\n")
+	if before.Equal(h.Snapshot()) {
+		t.Errorf("expected snapshots of different additional content not to be equal")
+	}
+}
+
+func TestSnapshot_Concurrent(t *testing.T) {
+	h, _ := newTestHostsFile(t, testSnapshotHosts)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			h.Add("10.0.1.1", []string{"a.local"}, "")
+			h.Remove("10.0.1.1", []string{"a.local"})
+			h.Remove("10.0.0.1", []string{"www.local"})
+			h.Add("10.0.0.1", []string{"www.local"}, "")
+			h.UpdateWhere(Hostname("api.local"), func(entry *HostEntry) {
+				entry.Comment = "updated"
+			})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			snapshot := h.Snapshot()
+			snapshot.LookupHost("api.local")
+			if !strings.Contains(snapshot.String(), "localhost") {
+				t.Errorf("unexpected content: %s", snapshot.String())
+			}
+		}
+	}()
+	wg.Wait()
+}
+
+func TestClone(t *testing.T) {
+	h, _ := newTestHostsFile(t, testSnapshotHosts)
+	h.OnAfterSave(func(event HookEvent) error { return nil })
+
+	expected := h.Snapshot().String()
+	clone := h.Clone()
+	if !Equal(clone.Entries(), h.Entries()) || clone.AdditionalContent() != h.AdditionalContent() {
+		t.Errorf("expected the clone to have the same content")
+	}
+
+	// The clone is independent from the hosts file
+	if err := clone.Add("10.0.0.5", []string{"new.local"}, "", WithTags(map[string]string{"owner": "clone"})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	clone.UpdateWhere(Tagged("owner"), func(entry *HostEntry) {
+		entry.Tags["owner"] = "clone"
+		entry.Hostnames[0] = "cloned.local"
+	})
+	if h.Snapshot().String() != expected {
+		t.Errorf("expected the hosts file to be unchanged, got:\n%s", h.Snapshot().String())
+	}
+	if h.Entries()[2].Tags["owner"] != "web" {
+		t.Errorf("expected the tags to be unchanged, got %v", h.Entries()[2].Tags)
+	}
+
+	// The clone keeps the options and hooks
+	if !clone.hasHooks(HookAfterSave) {
+		t.Errorf("expected the clone to have the hooks of the hosts file")
+	}
+	clone.OnBeforeSave(func(event HookEvent) error { return nil })
+	if h.hasHooks(HookBeforeSave) {
+		t.Errorf("expected the hooks registered on the clone not to be registered on the hosts file")
+	}
+}
+
+func TestEqual(t *testing.T) {
+	a := []HostEntry{
+		{Line: 1, IP: "10.0.0.1", Hostnames: []string{"api.local"}, Tags: map[string]string{}, Active: true},
+	}
+	b := []HostEntry{
+		{Line: 4, IP: "10.0.0.1", Hostnames: []string{"api.local"}, Active: true},
+	}
+
+	// Line numbers are not compared, and an empty set of tags equals no tags
+	if !Equal(a, b) || !a[0].Equal(b[0]) {
+		t.Errorf("expected the entries to be equal")
+	}
+
+	b[0].Comment = "comment"
+	if Equal(a, b) || a[0].Equal(b[0]) {
+		t.Errorf("expected entries with different comments not to be equal")
+	}
+	if Equal(a, nil) {
+		t.Errorf("expected entries of different lengths not to be equal")
+	}
+}
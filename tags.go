@@ -0,0 +1,138 @@
+package gohosts
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// tagRegexp matches a key=value metadata tag in a comment.
+var tagRegexp = regexp.MustCompile(`^([a-zA-Z0-9_.-]+)=([^\s#=]*)$`)
+
+// WithTags is an EntryOption that sets the metadata tags of the entry.
+func WithTags(tags map[string]string) EntryOption {
+	return func(e *HostEntry) {
+		if len(tags) == 0 {
+			return
+		}
+		e.Tags = copyTags(tags)
+	}
+}
+
+// parseTag splits a key=value tag, and reports whether the field is a valid tag.
+func parseTag(field string) (key, value string, ok bool) {
+	match := tagRegexp.FindStringSubmatch(field)
+	if match == nil {
+		return "", "", false
+	}
+	return match[1], match[2], true
+}
+
+// validateTags checks that the tags can be written in a comment and parsed back.
+func validateTags(tags map[string]string) error {
+	for key, value := range tags {
+		if _, _, ok := parseTag(key + "=" + value); !ok {
+			return fmt.Errorf("invalid tag: %s=%s", key, value)
+		}
+	}
+	return nil
+}
+
+// extractTags removes the key=value tags leading the provided comment, and returns the remaining
+// human text with the tags. Tags are written before the human text, so words further in the
+// comment are kept as text even if they look like tags. If the comment has no tags, the returned
+// tags are nil.
+func extractTags(comment string) (string, map[string]string) {
+	var tags map[string]string
+	rest := strings.TrimSpace(comment)
+
+	for rest != "" {
+		field, remaining := rest, ""
+		if i := strings.IndexFunc(rest, unicode.IsSpace); i != -1 {
+			field, remaining = rest[:i], rest[i:]
+		}
+		key, value, ok := parseTag(field)
+		if !ok {
+			break
+		}
+		if tags == nil {
+			tags = make(map[string]string)
+		}
+		tags[key] = value
+		rest = strings.TrimSpace(remaining)
+	}
+
+	if tags == nil {
+		return comment, nil
+	}
+	return rest, tags
+}
+
+// formatTags returns the tags as space separated key=value pairs, sorted by key.
+func formatTags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fields := make([]string, len(keys))
+	for i, key := range keys {
+		fields[i] = key + "=" + tags[key]
+	}
+	return strings.Join(fields, " ")
+}
+
+// FormatTags returns the metadata tags of the entry as space separated key=value pairs, sorted by
+// key, as they are written in the comment of the entry.
+func (e HostEntry) FormatTags() string {
+	return formatTags(e.Tags)
+}
+
+// copyTags returns a copy of the tags, so they can be changed without changing the original entry.
+func copyTags(tags map[string]string) map[string]string {
+	if tags == nil {
+		return nil
+	}
+	copied := make(map[string]string, len(tags))
+	for key, value := range tags {
+		copied[key] = value
+	}
+	return copied
+}
+
+// equalTags checks if two sets of tags are equal, a nil set equals an empty set.
+func equalTags(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if other, ok := b[key]; !ok || other != value {
+			return false
+		}
+	}
+	return true
+}
+
+// HasTag reports whether the entry has the tag with the provided key. If a value is provided,
+// the tag must also have that value.
+func (e HostEntry) HasTag(key string, value ...string) bool {
+	v, ok := e.Tags[key]
+	if !ok {
+		return false
+	}
+	return len(value) == 0 || v == value[0]
+}
+
+// FindByTag returns the entries having the tag with the provided key, and the provided value if any.
+func (h *HostsFile) FindByTag(key string, value ...string) []HostEntry {
+	return h.Filter(Tagged(key, value...))
+}
+
+// RemoveByTag removes the entries having the tag with the provided key, and the provided value if any.
+// It returns the number of removed entries.
+func (h *HostsFile) RemoveByTag(key string, value ...string) int {
+	return h.RemoveWhere(Tagged(key, value...))
+}
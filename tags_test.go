@@ -0,0 +1,150 @@
+package gohosts
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseHosts_Tags(t *testing.T) {
+	h := &HostsFile{}
+
+	lines := []string{
+		"10.0.0.1 payments.local # owner=payments ticket=OPS-12 note text",
+		"10.0.0.2 search.local # owner=search",
+		"10.0.0.3 plain.local # 1 + 1 = 2",
+		"10.0.0.4 doc.local # see doc a=b for details",
+	}
+
+	entries, err := h.parseHosts(lines)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []HostEntry{
+		{IP: "10.0.0.1", Hostnames: []string{"payments.local"}, Comment: "note text", Tags: map[string]string{"owner": "payments", "ticket": "OPS-12"}, Active: true},
+		{IP: "10.0.0.2", Hostnames: []string{"search.local"}, Tags: map[string]string{"owner": "search"}, Active: true},
+		{IP: "10.0.0.3", Hostnames: []string{"plain.local"}, Comment: "1 + 1 = 2", Active: true},
+		// Only the tags leading the comment are parsed
+		{IP: "10.0.0.4", Hostnames: []string{"doc.local"}, Comment: "see doc a=b for details", Active: true},
+	}
+
+	if !Equal(entries, expected) {
+		t.Errorf("Entries do not match expected entries. \nGot: %v, \nExpected: %v", entries, expected)
+	}
+}
+
+func TestAdd_WithTags(t *testing.T) {
+	tempFile, err := os.CreateTemp("", "hosts_test")
+	if err != nil {
+		t.Fatalf("Error creating temporary file: %v", err)
+	}
+	defer tempFile.Close()
+	defer os.Remove(tempFile.Name())
+
+	h, err := New(WithPath(tempFile.Name()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = h.Add("10.0.0.1", []string{"payments.local"}, "note text", WithTags(map[string]string{"ticket": "OPS-12", "owner": "payments"}))
+	if err != nil {
+		t.Fatalf("Error adding entry: %v", err)
+	}
+
+	// Test adding an entry with a tag that cannot be written
+	err = h.Add("10.0.0.2", []string{"invalid.local"}, "", WithTags(map[string]string{"owner": "two words"}))
+	if err == nil {
+		t.Error("Expected an error for entry with an invalid tag")
+	}
+
+	// Test adding an entry with a comment that would be read back as a tag
+	err = h.Add("10.0.0.3", []string{"invalid.local"}, "ticket=OPS-1 fix")
+	if !errors.Is(err, ErrInvalidComment) {
+		t.Errorf("Expected ErrInvalidComment for a comment starting with a tag, got %v", err)
+	}
+
+	err = h.Save()
+	if err != nil {
+		t.Fatalf("failed to save hosts file: %v", err)
+	}
+
+	content, err := os.ReadFile(tempFile.Name())
+	if err != nil {
+		t.Fatalf("failed to read hosts file: %v", err)
+	}
+	if !strings.Contains(string(content), "# owner=payments ticket=OPS-12 note text\n") {
+		t.Errorf("expected the tags to be written, got:\n%s", content)
+	}
+}
+
+func TestFindByTag(t *testing.T) {
+	h := &HostsFile{
+		entries: []HostEntry{
+			{IP: "10.0.0.1", Hostnames: []string{"payments.local"}, Tags: map[string]string{"owner": "payments"}},
+			{IP: "10.0.0.2", Hostnames: []string{"search.local"}, Tags: map[string]string{"owner": "search"}},
+			{IP: "10.0.0.3", Hostnames: []string{"plain.local"}},
+		},
+	}
+
+	if entries := h.FindByTag("owner"); len(entries) != 2 {
+		t.Errorf("expected 2 entries with an owner, got %d", len(entries))
+	}
+	if entries := h.FindByTag("owner", "search"); len(entries) != 1 || entries[0].IP != "10.0.0.2" {
+		t.Errorf("expected the search entry, got %v", entries)
+	}
+	if entries := h.FindByTag("ticket"); len(entries) != 0 {
+		t.Errorf("expected no entries, got %v", entries)
+	}
+}
+
+func TestRemoveByTag(t *testing.T) {
+	h := &HostsFile{
+		entries: []HostEntry{
+			{IP: "10.0.0.1", Hostnames: []string{"payments.local"}, Tags: map[string]string{"owner": "payments"}},
+			{IP: "10.0.0.2", Hostnames: []string{"search.local"}, Tags: map[string]string{"owner": "search"}},
+			{IP: "10.0.0.3", Hostnames: []string{"plain.local"}},
+		},
+	}
+
+	if removed := h.RemoveByTag("owner", "payments"); removed != 1 {
+		t.Errorf("expected 1 removed entry, got %d", removed)
+	}
+
+	expected := []HostEntry{
+		{IP: "10.0.0.2", Hostnames: []string{"search.local"}, Tags: map[string]string{"owner": "search"}},
+		{IP: "10.0.0.3", Hostnames: []string{"plain.local"}},
+	}
+	if !Equal(h.entries, expected) {
+		t.Errorf("Entries do not match expected entries. \nGot: %v, \nExpected: %v", h.entries, expected)
+	}
+}
+
+func TestHostEntry_FormatTags(t *testing.T) {
+	entry := HostEntry{IP: "10.0.0.1", Hostnames: []string{"api.local"}, Tags: map[string]string{"team": "dev", "owner": "web"}}
+	if tags := entry.FormatTags(); tags != "owner=web team=dev" {
+		t.Errorf("expected the tags sorted by key, got %q", tags)
+	}
+
+	if tags := (HostEntry{}).FormatTags(); tags != "" {
+		t.Errorf("expected no tags, got %q", tags)
+	}
+}
+
+func TestSave_TagLikeComment(t *testing.T) {
+	const content = "10.0.0.1     doc.local     # see doc a=b for details\n10.0.0.2     api.local     # owner=api fix ticket=OPS-1\n"
+	h, m := newTestHostsFile(t, content)
+
+	// Words that look like tags further in a comment are written back in place
+	if err := h.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := m.ReadFile("/etc/hosts")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != content {
+		t.Errorf("expected the hosts file to be unchanged, got:\n%s", data)
+	}
+}
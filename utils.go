@@ -2,7 +2,6 @@ package gohosts
 
 import (
 	"fmt"
-	"os"
 	"regexp"
 	"runtime"
 	"strings"
@@ -21,8 +20,8 @@ func getHostsFileLocation() (string, error) {
 }
 
 // isValidPath checks if the provided path is a valid file path.
-func isValidPath(path string) bool {
-	info, err := os.Stat(path)
+func isValidPath(fsys FS, path string) bool {
+	info, err := fsys.Stat(path)
 	if err != nil {
 		return false
 	}
@@ -67,49 +66,77 @@ func contains(slice []string, items ...string) bool {
 	return true
 }
 
-// removeStrings removes the provided values from the slice.
-func removeStrings(slice []string, values ...string) []string {
-	var result []string
-	for _, s := range slice {
-		if !contains(values, s) {
-			result = append(result, s)
+// equalStrings checks if two slices contain the same strings in the same order.
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
 		}
 	}
-	return result
+	return true
 }
 
-// compareEntrie checks if two HostEntry are equal.
-func compareEntrie(a, b HostEntry) bool {
-	if a.IP != b.IP {
+// Equal checks if the entry equals the other entry. Line numbers are not compared, so an entry
+// equals the same entry read from another line.
+func (e HostEntry) Equal(other HostEntry) bool {
+	if e.IP != other.IP {
 		return false
 	}
-	if len(a.Hostnames) != len(b.Hostnames) {
+	if !equalStrings(e.Hostnames, other.Hostnames) {
 		return false
 	}
-	for i := range a.Hostnames {
-		if a.Hostnames[i] != b.Hostnames[i] {
-			return false
-		}
+	if e.Comment != other.Comment {
+		return false
+	}
+	if !equalTags(e.Tags, other.Tags) {
+		return false
+	}
+	if e.Active != other.Active {
+		return false
 	}
-	if a.Comment != b.Comment {
+	if !e.Expires.Equal(other.Expires) {
 		return false
 	}
-	if a.Active != b.Active {
+	if e.Block != other.Block {
 		return false
 	}
 
 	return true
 }
 
-// compareEntries checks if two slices of HostEntry are equal.
-func compareEntries(a, b []HostEntry) bool {
+// Equal checks if two slices of entries are equal, in order, see HostEntry.Equal.
+func Equal(a, b []HostEntry) bool {
 	if len(a) != len(b) {
 		return false
 	}
 	for i := range a {
-		if !compareEntrie(a[i], b[i]) {
+		if !a[i].Equal(b[i]) {
 			return false
 		}
 	}
 	return true
 }
+
+// cloneEntry returns a copy of the entry not sharing its hostnames and tags with it.
+func cloneEntry(entry HostEntry) HostEntry {
+	if entry.Hostnames != nil {
+		entry.Hostnames = append([]string(nil), entry.Hostnames...)
+	}
+	entry.Tags = copyTags(entry.Tags)
+	return entry
+}
+
+// cloneEntries returns a copy of the entries not sharing any hostnames or tags with them.
+func cloneEntries(entries []HostEntry) []HostEntry {
+	if entries == nil {
+		return nil
+	}
+	cloned := make([]HostEntry, len(entries))
+	for i, entry := range entries {
+		cloned[i] = cloneEntry(entry)
+	}
+	return cloned
+}